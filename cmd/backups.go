@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ohhmaar/docmd/internal/backup"
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/gdrive"
+)
+
+// defaultBackupKeep is how many backups are kept per doc when the config
+// doesn't say.
+const defaultBackupKeep = 20
+
+var backupsVariant string
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "List and restore backups of linked docs",
+	Long: `Before a push overwrites changes made in a doc since the last sync
+(after choosing to push local on a conflict, with --force, or in watch
+mode), docmd exports the doc as HTML and markdown to
+~/.docmd/backups/<doc-id>/.
+
+The newest 20 backups of each doc are kept. Change this with "keep" and
+"max_age_days" under "backups" in the config, or turn backups off with
+"disabled".`,
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:   "list [file.md]",
+	Short: "List backups, of all docs or of a file's doc",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBackupsList,
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore <file.md> [backup-id]",
+	Short: "Replace a linked doc's content with a backup",
+	Long: `Replace the content of a file's doc with a backup, the newest one
+unless an ID from 'docmd backups list' is given. The current content is
+backed up first. The file itself is not changed, so the next push will
+report a conflict.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runBackupsRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(backupsCmd)
+	backupsCmd.AddCommand(backupsListCmd)
+	backupsCmd.AddCommand(backupsRestoreCmd)
+	backupsCmd.PersistentFlags().StringVar(&backupsVariant, "variant", "", "Variant of the link")
+}
+
+// backupRemote saves a copy of every doc of the link that was edited since
+// the last sync, before a push overwrites it. It reports whether any was.
+func backupRemote(cfg *config.Config, filePath string, link *config.Link) (bool, error) {
+	// The docs of a split link share the backup ID, so they can be
+	// restored together.
+	at := time.Now()
+	changed := false
+	for _, docID := range linkDocIDs(link) {
+		info, err := docStore.GetDocInfo(docID)
+		if err != nil {
+			return false, err
+		}
+		if !link.LastSync.IsZero() && !info.ModifiedTime.After(link.LastSync) {
+			continue
+		}
+		changed = true
+
+		if cfg.Backups != nil && cfg.Backups.Disabled {
+			continue
+		}
+		b, err := backupDoc(cfg, info, filePath, at)
+		if err != nil {
+			return false, err
+		}
+		printInfo(fmt.Sprintf("Backed up the changes in %q (%s)", info.Title, b.ID))
+	}
+
+	return changed, nil
+}
+
+// backupDoc exports a doc into a new backup taken at the given time, which
+// sets its ID, and applies the retention settings.
+func backupDoc(cfg *config.Config, info *gdrive.DocInfo, source string, at time.Time) (*backup.Backup, error) {
+	htmlContent, err := docStore.ExportDoc(info.ID, "text/html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up %q: %w", info.Title, err)
+	}
+
+	// The markdown export is a convenience; the HTML is the backup.
+	markdown, err := docStore.ExportDoc(info.ID, "text/markdown")
+	if err != nil {
+		markdown = nil
+	}
+
+	b := &backup.Backup{
+		DocID:       info.ID,
+		Title:       info.Title,
+		Source:      source,
+		CreatedAt:   at,
+		DocModified: info.ModifiedTime,
+		ModifiedBy:  info.ModifiedBy,
+	}
+	if err := backup.Save(b, htmlContent, markdown); err != nil {
+		return nil, err
+	}
+
+	keep, maxAge := defaultBackupKeep, time.Duration(0)
+	if bc := cfg.Backups; bc != nil {
+		if bc.Keep > 0 {
+			keep = bc.Keep
+		}
+		maxAge = time.Duration(bc.MaxAgeDays) * 24 * time.Hour
+	}
+	if _, err := backup.Prune(info.ID, keep, maxAge); err != nil {
+		printWarning(fmt.Sprintf("Failed to remove old backups: %v", err))
+	}
+
+	return b, nil
+}
+
+func runBackupsList(cmd *cobra.Command, args []string) error {
+	var backups []*backup.Backup
+
+	if len(args) == 1 {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		link, err := backupsLink(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		for _, docID := range linkDocIDs(link) {
+			docBackups, err := backup.List(docID)
+			if err != nil {
+				return err
+			}
+			backups = append(backups, docBackups...)
+		}
+	} else {
+		var err error
+		if backups, err = backup.ListAll(); err != nil {
+			return err
+		}
+	}
+
+	if len(backups) == 0 {
+		printInfo("No backups.")
+		return nil
+	}
+
+	for _, b := range backups {
+		fmt.Printf("  %s  %q\n", b.ID, b.Title)
+		fmt.Printf("    Doc modified: %s", b.DocModified.Local().Format("2006-01-02 15:04:05"))
+		if b.ModifiedBy != "" {
+			fmt.Printf(" by %s", b.ModifiedBy)
+		}
+		fmt.Println()
+		if b.Source != "" {
+			fmt.Printf("    Source: %s\n", b.Source)
+		}
+		fmt.Printf("    Files: %s\n", b.Dir())
+	}
+
+	return nil
+}
+
+func runBackupsRestore(cmd *cobra.Command, args []string) error {
+	if !authenticated() {
+		printError("Not authenticated!")
+		fmt.Println("Run 'docmd init' first to authenticate with Google.")
+		return fmt.Errorf("not authenticated")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	link, err := backupsLink(cfg, args[0])
+	if err != nil {
+		return err
+	}
+	if link.Tabs {
+		return fmt.Errorf("restoring tabbed docs isn't supported; the backup HTML can be opened from 'docmd backups list'")
+	}
+
+	// A push backs up all changed docs of a split link with the same ID,
+	// so restoring an ID restores every doc that has a backup with it.
+	// Without an ID the newest backup picks the ID.
+	var all []*backup.Backup
+	for _, docID := range linkDocIDs(link) {
+		docBackups, err := backup.List(docID)
+		if err != nil {
+			return err
+		}
+		all = append(all, docBackups...)
+	}
+
+	id := ""
+	if len(args) == 2 {
+		id = args[1]
+	} else if len(all) > 0 {
+		newest := all[0]
+		for _, b := range all[1:] {
+			if b.CreatedAt.After(newest.CreatedAt) {
+				newest = b
+			}
+		}
+		id = newest.ID
+	}
+
+	var restore []*backup.Backup
+	for _, b := range all {
+		if b.ID == id {
+			restore = append(restore, b)
+		}
+	}
+	if len(restore) == 0 {
+		if len(args) < 2 {
+			return fmt.Errorf("no backups of %s", filepath.Base(args[0]))
+		}
+		return fmt.Errorf("no backup %s of %s", id, filepath.Base(args[0]))
+	}
+
+	at := time.Now()
+	for _, b := range restore {
+		// Read first: backing up the current content may prune b.
+		htmlContent, err := b.HTML()
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		info, err := docStore.GetDocInfo(b.DocID)
+		if err != nil {
+			return err
+		}
+		current, err := backupDoc(cfg, info, b.Source, at)
+		if err != nil {
+			return err
+		}
+
+		if _, err := docStore.UpdateDoc(b.DocID, string(htmlContent)); err != nil {
+			return fmt.Errorf("failed to restore %q: %w", b.Title, err)
+		}
+
+		printSuccess(fmt.Sprintf("Restored %q from %s (previous content backed up as %s)", b.Title, b.ID, current.ID))
+	}
+
+	absPath, _ := filepath.Abs(args[0])
+	if err := postUpload(cfg, absPath, link); err != nil {
+		printWarning(fmt.Sprintf("Failed to finish document: %v", err))
+	}
+
+	fmt.Println("The doc no longer matches the file; the next push will report a conflict.")
+	return nil
+}
+
+func backupsLink(cfg *config.Config, filePath string) (*config.Link, error) {
+	absPath, _ := filepath.Abs(filePath)
+	link, ok := cfg.GetLink(config.LinkKey(absPath, backupsVariant))
+	if !ok {
+		return nil, fmt.Errorf("file not linked: %s", filePath)
+	}
+	return link, nil
+}
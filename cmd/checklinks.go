@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/convert"
+)
+
+var (
+	checkExternal bool
+	checkOffline  bool
+)
+
+var docURLPattern = regexp.MustCompile(`^https://docs\.google\.com/document/d/([A-Za-z0-9_-]+)`)
+
+var checkLinksCmd = &cobra.Command{
+	Use:   "check-links [file.md...]",
+	Short: "Report broken links in linked files",
+	Long: `Check the links of markdown files and report broken ones with
+file:line positions. Without arguments all linked files are checked.
+
+Checked are links to local files, anchors of headings in the same or
+another file, links to files that aren't linked (they won't resolve in
+Google Docs) and links to docs that were deleted or moved to the trash.
+
+Local targets are checked offline. Looking up docs needs Drive access and
+is skipped with --offline. External URLs are only requested with
+--external.`,
+	RunE: runCheckLinks,
+}
+
+func init() {
+	rootCmd.AddCommand(checkLinksCmd)
+	checkLinksCmd.Flags().BoolVar(&checkExternal, "external", false, "Also request external URLs")
+	checkLinksCmd.Flags().BoolVar(&checkOffline, "offline", false, "Don't look up linked docs on Google Drive")
+}
+
+func runCheckLinks(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	files := args
+	if len(files) == 0 {
+		files = linkedFiles(cfg)
+		if len(files) == 0 {
+			printWarning("No linked files.")
+			fmt.Println("Use 'docmd check-links <file.md>' to check a file that isn't linked.")
+			return nil
+		}
+	}
+
+	online := !checkOffline
+	if online && !authenticated() {
+		printInfo("Not authenticated; skipping checks of linked docs")
+		online = false
+	}
+
+	checker := &linkChecker{
+		cfg:      cfg,
+		online:   online,
+		external: checkExternal,
+		docs:     make(map[string]string),
+		urls:     make(map[string]string),
+		anchors:  make(map[string]map[string]bool),
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+
+	var issues []convert.Issue
+	for _, f := range files {
+		absPath, _ := filepath.Abs(f)
+		fileIssues, err := checker.checkFile(absPath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+		issues = append(issues, fileIssues...)
+	}
+
+	printIssues(issues)
+
+	if len(issues) == 0 {
+		printSuccess("No broken links found")
+		return nil
+	}
+
+	fmt.Printf("\n%d broken link(s) found\n", len(issues))
+	return fmt.Errorf("found %d broken link(s)", len(issues))
+}
+
+// linkChecker checks link targets, remembering results so that a target
+// linked from several places is only looked up once.
+type linkChecker struct {
+	cfg      *config.Config
+	online   bool
+	external bool
+	docs     map[string]string
+	urls     map[string]string
+	anchors  map[string]map[string]bool
+	client   *http.Client
+}
+
+func (c *linkChecker) checkFile(filePath string) ([]convert.Issue, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found")
+	}
+
+	switch convert.FormatFor(filePath).Name() {
+	case "book":
+		files, err := convert.BookFiles(filePath)
+		if err != nil {
+			return nil, err
+		}
+		var issues []convert.Issue
+		for _, f := range files {
+			fileIssues, err := c.checkFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filepath.Base(f), err)
+			}
+			issues = append(issues, fileIssues...)
+		}
+		return issues, nil
+	case "markdown":
+	default:
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	links, anchors := convert.MarkdownLinks(filePath, data)
+	c.anchors[filePath] = anchors
+
+	var issues []convert.Issue
+	for _, l := range links {
+		if problem := c.check(l.Target, filePath); problem != "" {
+			issues = append(issues, convert.Issue{File: l.File, Line: l.Line, Message: problem})
+		}
+	}
+	return issues, nil
+}
+
+// check returns what is wrong with a link target, or "" when it is fine.
+func (c *linkChecker) check(target string, filePath string) string {
+	if anchor, ok := strings.CutPrefix(target, "#"); ok {
+		if anchor != "" && !c.anchors[filePath][anchor] {
+			return fmt.Sprintf("anchor not found: %s", target)
+		}
+		return ""
+	}
+
+	if path, fragment, ok := convert.LocalTarget(target, filepath.Dir(filePath)); ok {
+		return c.checkLocal(target, path, fragment)
+	}
+
+	if m := docURLPattern.FindStringSubmatch(target); m != nil {
+		return c.checkDoc(m[1], target)
+	}
+
+	if c.external && (strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")) {
+		return c.checkURL(target)
+	}
+
+	return ""
+}
+
+func (c *linkChecker) checkLocal(target, path, fragment string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Sprintf("file not found: %s", target)
+	}
+	if info.IsDir() {
+		return ""
+	}
+
+	if fragment != "" && convert.FormatFor(path).Name() == "markdown" {
+		anchors, ok := c.anchors[path]
+		if !ok {
+			anchors, _ = convert.HeadingAnchors(path)
+			c.anchors[path] = anchors
+		}
+		if !anchors[fragment] {
+			return fmt.Sprintf("anchor not found: %s", target)
+		}
+	}
+
+	link, ok := fileLink(c.cfg, path)
+	if !ok {
+		return fmt.Sprintf("%s is not linked; the link won't resolve in Google Docs", target)
+	}
+
+	return c.checkDoc(link.DocID, target)
+}
+
+// checkDoc looks up a doc on Drive. Without Drive access every doc passes.
+func (c *linkChecker) checkDoc(docID, target string) string {
+	if problem, ok := c.docs[docID]; ok {
+		return problem
+	}
+
+	problem := ""
+	if c.online {
+		info, err := docStore.GetDocInfo(docID)
+		switch {
+		case err != nil:
+			problem = fmt.Sprintf("doc not found or not accessible: %s", target)
+		case info.Trashed:
+			problem = fmt.Sprintf("doc is in the trash: %s", target)
+		}
+	}
+
+	c.docs[docID] = problem
+	return problem
+}
+
+func (c *linkChecker) checkURL(target string) string {
+	if problem, ok := c.urls[target]; ok {
+		return problem
+	}
+
+	problem := ""
+	resp, err := c.client.Head(target)
+	if err == nil && resp.StatusCode >= 400 {
+		// Some servers don't answer HEAD requests.
+		resp.Body.Close()
+		resp, err = c.client.Get(target)
+	}
+	if err != nil {
+		problem = fmt.Sprintf("unreachable: %v", err)
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			problem = fmt.Sprintf("%s returns HTTP %d", target, resp.StatusCode)
+		}
+	}
+
+	c.urls[target] = problem
+	return problem
+}
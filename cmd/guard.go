@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/convert"
+)
+
+// defaultMaxShrink is the share of a doc a push may remove without
+// confirmation when the config doesn't set max_shrink.
+const defaultMaxShrink = 0.5
+
+var errShrinkRefused = errors.New("push would remove most of the doc")
+
+// confirmFunc asks whether an upload that removes much of the doc should go
+// ahead. A nil confirmFunc refuses, as automatic pushes should.
+type confirmFunc func(reason string) bool
+
+// guardShrink compares the rendered content with the size of the last push
+// and asks for confirmation when it removes more than the configured share
+// of the text or blocks, or empties the doc. It returns the size to record
+// once the upload succeeded.
+func guardShrink(cfg *config.Config, link *config.Link, confirm confirmFunc, contents ...string) (*config.ContentSize, error) {
+	size := &config.ContentSize{}
+	for _, content := range contents {
+		chars, blocks := convert.MeasureHTML(content)
+		size.Chars += chars
+		size.Blocks += blocks
+	}
+
+	reason := shrinkReason(link.PushedSize, size, maxShrink(cfg))
+	if reason == "" {
+		return size, nil
+	}
+
+	if confirm == nil || !confirm(reason) {
+		return nil, fmt.Errorf("%w: %s", errShrinkRefused, reason)
+	}
+	return size, nil
+}
+
+func maxShrink(cfg *config.Config) float64 {
+	if cfg.MaxShrink > 0 {
+		return cfg.MaxShrink
+	}
+	return defaultMaxShrink
+}
+
+// shrinkReason describes how much of the previous content would be removed,
+// or returns "" when the change is within bounds.
+func shrinkReason(prev, next *config.ContentSize, limit float64) string {
+	if prev == nil || prev.Chars == 0 {
+		return ""
+	}
+
+	if next.Chars == 0 {
+		return "the new content is empty"
+	}
+
+	if removed := shrinkShare(prev.Chars, next.Chars); removed > limit {
+		return fmt.Sprintf("it removes %.0f%% of the text (%d of %d characters left)", removed*100, next.Chars, prev.Chars)
+	}
+	if removed := shrinkShare(prev.Blocks, next.Blocks); removed > limit {
+		return fmt.Sprintf("it removes %.0f%% of the paragraphs, headings and list items (%d of %d left)", removed*100, next.Blocks, prev.Blocks)
+	}
+	return ""
+}
+
+func shrinkShare(prev, next int) float64 {
+	if prev == 0 || next >= prev {
+		return 0
+	}
+	return float64(prev-next) / float64(prev)
+}
+
+// confirmShrink asks on the terminal whether to push anyway.
+func confirmShrink(reason string) bool {
+	fmt.Println()
+	printWarning("This push would remove most of the doc: " + reason + ".")
+	fmt.Println("  If the file was truncated by accident, restore it before pushing.")
+	fmt.Print("Push anyway? [y/N]: ")
+
+	reader := bufio.NewReader(os.Stdin)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+
+	return input == "y" || input == "yes"
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ohhmaar/docmd/internal/config"
+)
+
+func TestShrinkReason(t *testing.T) {
+	size := func(chars, blocks int) *config.ContentSize {
+		return &config.ContentSize{Chars: chars, Blocks: blocks}
+	}
+
+	tests := []struct {
+		name  string
+		prev  *config.ContentSize
+		next  *config.ContentSize
+		limit float64
+		want  string
+	}{
+		{"first push", nil, size(10, 1), 0.5, ""},
+		{"empty doc before", size(0, 0), size(10, 1), 0.5, ""},
+		{"growth", size(100, 10), size(200, 20), 0.5, ""},
+		{"within the limit", size(100, 10), size(50, 5), 0.5, ""},
+		{"emptied", size(100, 10), size(0, 0), 0.5, "empty"},
+		{"text removed", size(100, 10), size(40, 10), 0.5, "60% of the text (40 of 100 characters left)"},
+		{"blocks removed", size(100, 10), size(90, 2), 0.5, "80% of the paragraphs"},
+		{"lower limit", size(100, 10), size(60, 10), 0.3, "40% of the text"},
+		{"limit 1 only guards emptying", size(100, 10), size(1, 1), 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shrinkReason(tt.prev, tt.next, tt.limit)
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("shrinkReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardShrink(t *testing.T) {
+	link := &config.Link{PushedSize: &config.ContentSize{Chars: 1000, Blocks: 10}}
+	short := "<p>Short.</p>"
+
+	if _, err := guardShrink(&config.Config{}, link, nil, short); !errors.Is(err, errShrinkRefused) {
+		t.Errorf("without confirm: got %v, want errShrinkRefused", err)
+	}
+
+	refuse := func(string) bool { return false }
+	if _, err := guardShrink(&config.Config{}, link, refuse, short); !errors.Is(err, errShrinkRefused) {
+		t.Errorf("refused: got %v, want errShrinkRefused", err)
+	}
+
+	asked := ""
+	accept := func(reason string) bool { asked = reason; return true }
+	size, err := guardShrink(&config.Config{}, link, accept, short, short)
+	if err != nil {
+		t.Fatalf("confirmed: %v", err)
+	}
+	if asked == "" {
+		t.Error("confirm wasn't asked")
+	}
+	if size.Chars != 12 || size.Blocks != 2 {
+		t.Errorf("size = %+v, want both contents counted", size)
+	}
+
+	if _, err := guardShrink(&config.Config{MaxShrink: 1}, link, nil, short); err != nil {
+		t.Errorf("max_shrink 1: got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ohhmaar/docmd/internal/config"
+)
+
+// maxPushedRevisions bounds the revisions remembered per link.
+const maxPushedRevisions = 50
+
+var (
+	historyVariant string
+	rollbackTo     string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <file.md>",
+	Short: "List the revisions of a file's doc",
+	Long: `List the Drive revisions of a linked doc, marking the ones created by
+docmd pushes with the hash of the file they were rendered from.
+
+Drive merges and drops old revisions of Google Docs over time, so pushes
+may disappear from the list. Drive can only be asked to keep revisions of
+files with binary content, so the revisions of docs can't be pinned.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <file.md> --to <revision>",
+	Short: "Restore an earlier revision of a file's doc",
+	Long: `Replace the content of a linked doc with an earlier revision from
+'docmd history'. The current content is backed up first. The file itself
+is not changed, so the next push will report a conflict.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRollback,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	historyCmd.Flags().StringVar(&historyVariant, "variant", "", "Variant of the link")
+	rollbackCmd.Flags().StringVar(&historyVariant, "variant", "", "Variant of the link")
+	rollbackCmd.Flags().StringVar(&rollbackTo, "to", "", "Revision ID to restore")
+	rollbackCmd.MarkFlagRequired("to")
+}
+
+// recordRevisions remembers the current revisions of the link's docs as
+// pushed from the file.
+func recordRevisions(filePath string, link *config.Link) {
+	hash, _ := config.HashFile(filePath)
+
+	for _, docID := range linkDocIDs(link) {
+		rev, err := docStore.LatestRevision(docID)
+		if err != nil {
+			printWarning(fmt.Sprintf("Failed to record the pushed revision: %v", err))
+			continue
+		}
+
+		pushed := &config.PushedRevision{
+			DocID:      docID,
+			RevisionID: rev.ID,
+			Hash:       hash,
+			PushedAt:   time.Now(),
+		}
+		link.Revisions = append(link.Revisions, pushed)
+	}
+
+	if extra := len(link.Revisions) - maxPushedRevisions; extra > 0 {
+		link.Revisions = link.Revisions[extra:]
+	}
+}
+
+func pushedRevision(link *config.Link, docID, revisionID string) *config.PushedRevision {
+	for _, r := range link.Revisions {
+		if r.DocID == docID && r.RevisionID == revisionID {
+			return r
+		}
+	}
+	return nil
+}
+
+func historyLink(cfg *config.Config, filePath string) (string, *config.Link, error) {
+	absPath, _ := filepath.Abs(filePath)
+	link, ok := cfg.GetLink(config.LinkKey(absPath, historyVariant))
+	if !ok {
+		return "", nil, fmt.Errorf("file not linked: %s", filePath)
+	}
+	return absPath, link, nil
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if !authenticated() {
+		printError("Not authenticated!")
+		fmt.Println("Run 'docmd init' first to authenticate with Google.")
+		return fmt.Errorf("not authenticated")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	absPath, link, err := historyLink(cfg, args[0])
+	if err != nil {
+		return err
+	}
+	currentHash, _ := config.HashFile(absPath)
+
+	for _, docID := range linkDocIDs(link) {
+		info, err := docStore.GetDocInfo(docID)
+		if err != nil {
+			return err
+		}
+		revisions, err := docStore.ListRevisions(docID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s (%d revision(s))\n", info.Title, len(revisions))
+		for i := len(revisions) - 1; i >= 0; i-- {
+			r := revisions[i]
+			fmt.Printf("  %-8s %s  %s", r.ID, r.ModifiedTime.Local().Format("2006-01-02 15:04:05"), r.ModifiedBy)
+			if pushed := pushedRevision(link, docID, r.ID); pushed != nil {
+				fmt.Printf("  docmd push (%s", shortHash(pushed.Hash))
+				if pushed.Hash == currentHash {
+					fmt.Print(", matches the file")
+				}
+				fmt.Print(")")
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	if !authenticated() {
+		printError("Not authenticated!")
+		fmt.Println("Run 'docmd init' first to authenticate with Google.")
+		return fmt.Errorf("not authenticated")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	absPath, link, err := historyLink(cfg, args[0])
+	if err != nil {
+		return err
+	}
+	if link.Tabs || link.Split > 0 {
+		return fmt.Errorf("rollback isn't supported for tabbed or split docs; see 'docmd backups restore'")
+	}
+
+	htmlContent, err := docStore.RevisionHTML(link.DocID, rollbackTo)
+	if err != nil {
+		return err
+	}
+
+	info, err := docStore.GetDocInfo(link.DocID)
+	if err != nil {
+		return err
+	}
+	current, err := backupDoc(cfg, info, absPath, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, err := docStore.UpdateDoc(link.DocID, htmlContent); err != nil {
+		return fmt.Errorf("failed to restore revision: %w", err)
+	}
+
+	if err := postUpload(cfg, absPath, link); err != nil {
+		printWarning(fmt.Sprintf("Failed to finish document: %v", err))
+	}
+
+	printSuccess(fmt.Sprintf("Rolled %q back to revision %s (previous content backed up as %s)", info.Title, rollbackTo, current.ID))
+
+	if pushed := pushedRevision(link, link.DocID, rollbackTo); pushed != nil {
+		fmt.Printf("  That revision was pushed from the file at %s (%s).\n", pushed.PushedAt.Format("2006-01-02 15:04:05"), shortHash(pushed.Hash))
+	}
+	fmt.Println("The doc no longer matches the file; the next push will report a conflict.")
+
+	return nil
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/ohhmaar/docmd/internal/auth"
 	"github.com/ohhmaar/docmd/internal/config"
 	"github.com/ohhmaar/docmd/internal/convert"
 	"github.com/ohhmaar/docmd/internal/gdrive"
@@ -18,16 +17,54 @@ import (
 var (
 	linkTitle    string
 	linkFolderID string
+	linkNoBanner bool
+	linkVariant  string
+	linkTags     []string
+	linkHideCode bool
+	linkHideOut  bool
+	linkVault    string
+	linkChips    bool
+	linkNumber   bool
+	linkNumFrom  int
+	linkPage     config.PageConfig
+	linkTabs     bool
+	linkTabFiles []string
+	linkSplit    int
+	linkBreaks   bool
 )
 
 var linkCmd = &cobra.Command{
-	Use:   "link <file.md>",
+	Use:   "link <file.md> [more.md...]",
 	Short: "Link a markdown file to a new Google Doc",
 	Long: `Create a new Google Doc from a markdown file and link them.
 
 The markdown file will be converted to HTML and uploaded to Google Docs.
-Future changes can be synced using 'docmd push'.`,
-	Args: cobra.ExactArgs(1),
+Future changes can be synced using 'docmd push'.
+
+Tags select which <!-- docmd:if key=value --> blocks are kept. To feed
+several differently filtered docs from the same file, link it again
+with a --variant name and a different set of tags.
+
+Jupyter notebooks (.ipynb) can be linked too: markdown cells are rendered
+as usual, code cells become code blocks and outputs are included below
+them unless hidden with --hide-code or --hide-outputs.
+
+--number-headings prefixes headings with section numbers (1, 1.1, 1.2.3).
+Use --number-from 2 to leave a title H1 unnumbered.
+
+Page settings (--page-size, --orientation, --margins, --pageless) are
+applied after every upload. They can also be set in the file's front
+matter as page_size, page_orientation, page_margins and pageless.
+
+With --tabs every H1 section becomes a tab of a single doc. With
+--tab-file the linked file is the first tab and each tab file another.
+
+With --split N the file is cut at every level N heading into one doc per
+section, created in a new folder named after the file.
+
+Several files, or a .book manifest listing them, are combined into one
+doc in order. Use --page-breaks to start every file on a new page.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: runLink,
 }
 
@@ -35,12 +72,29 @@ func init() {
 	rootCmd.AddCommand(linkCmd)
 	linkCmd.Flags().StringVarP(&linkTitle, "title", "t", "", "Custom title for the Google Doc (default: filename)")
 	linkCmd.Flags().StringVarP(&linkFolderID, "folder", "f", "", "Google Drive folder ID to create the doc in")
+	linkCmd.Flags().BoolVar(&linkNoBanner, "no-banner", false, "Don't add the generated-from-source banner to this doc")
+	linkCmd.Flags().StringVar(&linkVariant, "variant", "", "Name for an additional link of the same file")
+	linkCmd.Flags().StringArrayVar(&linkTags, "tag", nil, "Active tag for conditional blocks as key=value (repeatable)")
+	linkCmd.Flags().BoolVar(&linkHideCode, "hide-code", false, "Leave out code cells when rendering a notebook")
+	linkCmd.Flags().BoolVar(&linkHideOut, "hide-outputs", false, "Leave out cell outputs when rendering a notebook")
+	linkCmd.Flags().StringVar(&linkVault, "vault", "", "Obsidian vault root for resolving [[wikilinks]] and embeds")
+	linkCmd.Flags().BoolVar(&linkChips, "person-chips", false, "Turn @user@example.com mentions into person chips")
+	linkCmd.Flags().BoolVar(&linkNumber, "number-headings", false, "Number headings as 1, 1.1, 1.2.3")
+	linkCmd.Flags().IntVar(&linkNumFrom, "number-from", 0, "Heading level numbering starts at (default 1)")
+	linkCmd.Flags().StringVar(&linkPage.Size, "page-size", "", "Page size: a4, letter, legal, a3, a5 or tabloid")
+	linkCmd.Flags().StringVar(&linkPage.Orientation, "orientation", "", "Page orientation: portrait or landscape")
+	linkCmd.Flags().StringVar(&linkPage.Margins, "margins", "", "Page margins, e.g. 2cm or \"1in 0.75in\"")
+	linkCmd.Flags().BoolVar(&linkPage.Pageless, "pageless", false, "Use pageless mode")
+	linkCmd.Flags().BoolVar(&linkTabs, "tabs", false, "Put each H1 section in its own tab")
+	linkCmd.Flags().StringArrayVar(&linkTabFiles, "tab-file", nil, "Additional file to add as a tab (repeatable)")
+	linkCmd.Flags().IntVar(&linkSplit, "split", 0, "Create one doc per section, cutting at headings of this level")
+	linkCmd.Flags().BoolVar(&linkBreaks, "page-breaks", false, "Start every file of a book on a new page")
 }
 
 func runLink(cmd *cobra.Command, args []string) error {
 	filePath := args[0]
 
-	if !auth.TokenExists() {
+	if !authenticated() {
 		printError("Not authenticated!")
 		fmt.Println("Run 'docmd init' first to authenticate with Google.")
 		return fmt.Errorf("not authenticated")
@@ -55,8 +109,13 @@ func runLink(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	tags, err := parseTags(linkTags)
+	if err != nil {
+		return err
+	}
+
 	absPath, _ := filepath.Abs(filePath)
-	if link, exists := cfg.GetLink(absPath); exists {
+	if link, exists := cfg.GetLink(config.LinkKey(absPath, linkVariant)); exists {
 		printWarning("File is already linked!")
 		fmt.Printf("  Doc URL: %s\n", link.DocURL)
 		fmt.Println()
@@ -68,31 +127,98 @@ func runLink(cmd *cobra.Command, args []string) error {
 	if title == "" {
 		base := filepath.Base(filePath)
 		title = strings.TrimSuffix(base, filepath.Ext(base))
+		if linkVariant != "" {
+			title += " (" + linkVariant + ")"
+		}
 	}
 
-	fmt.Printf("Creating Google Doc from %s...\n", filePath)
+	link := &config.Link{
+		NoBanner:       linkNoBanner,
+		Variant:        linkVariant,
+		Tags:           tags,
+		HideCode:       linkHideCode,
+		HideOutputs:    linkHideOut,
+		PersonChips:    linkChips,
+		NumberHeadings: linkNumber,
+		NumberFrom:     linkNumFrom,
+	}
 
-	htmlContent, err := convert.FileToHTML(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to convert markdown: %w", err)
+	if linkPage != (config.PageConfig{}) {
+		if _, err := gdrive.NewPageSetup(linkPage.Size, linkPage.Orientation, linkPage.Margins, linkPage.Pageless); err != nil {
+			return err
+		}
+		page := linkPage
+		link.Page = &page
+	}
+
+	for _, f := range args[1:] {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return fmt.Errorf("invalid file: %w", err)
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return fmt.Errorf("file not found: %s", f)
+		}
+		link.BookFiles = append(link.BookFiles, abs)
+	}
+	link.PageBreaks = linkBreaks
+
+	if len(link.BookFiles) > 0 && (linkSplit > 0 || linkTabs || len(linkTabFiles) > 0) {
+		return fmt.Errorf("several files can't be combined with --split or tabs")
+	}
+
+	if linkSplit < 0 || linkSplit > 6 {
+		return fmt.Errorf("--split must be a heading level from 1 to 6")
+	}
+	if linkSplit > 0 && (linkTabs || len(linkTabFiles) > 0) {
+		return fmt.Errorf("--split can't be combined with tabs")
+	}
+	link.Split = linkSplit
+
+	if linkTabs || len(linkTabFiles) > 0 {
+		link.Tabs = true
+		for _, f := range linkTabFiles {
+			abs, err := filepath.Abs(f)
+			if err != nil {
+				return fmt.Errorf("invalid tab file: %w", err)
+			}
+			if _, err := os.Stat(abs); err != nil {
+				return fmt.Errorf("tab file not found: %s", f)
+			}
+			link.TabFiles = append(link.TabFiles, abs)
+		}
 	}
 
-	docInfo, err := gdrive.CreateDoc(title, htmlContent, linkFolderID)
+	if linkVault != "" {
+		vault, err := filepath.Abs(linkVault)
+		if err != nil {
+			return fmt.Errorf("invalid vault path: %w", err)
+		}
+		link.VaultRoot = vault
+	}
+
+	fmt.Printf("Creating Google Doc from %s...\n", filePath)
+
+	docInfo, rendered, err := createDoc(cfg, absPath, title, link)
 	if err != nil {
-		return fmt.Errorf("failed to create Google Doc: %w", err)
+		return err
 	}
 
 	hash, _ := config.HashFile(absPath)
 
-	link := &config.Link{
-		DocID:           docInfo.ID,
-		DocURL:          docInfo.URL,
-		Title:           docInfo.Title,
-		CreatedAt:       time.Now(),
-		LastSync:        time.Now(),
-		LocalHashAtSync: hash,
+	if err := finishUpload(cfg, absPath, link, rendered); err != nil {
+		printWarning(fmt.Sprintf("Failed to finish document: %v", err))
 	}
 
+	recordRevisions(absPath, link)
+	recordFingerprint(absPath, link)
+
+	link.DocURL = docInfo.URL
+	link.Title = docInfo.Title
+	link.CreatedAt = time.Now()
+	link.LastSync = time.Now()
+	link.LocalHashAtSync = hash
+
 	if err := cfg.AddLink(absPath, link); err != nil {
 		return fmt.Errorf("failed to save link: %w", err)
 	}
@@ -105,3 +231,103 @@ func runLink(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// createDoc creates the doc (or folder, for a split link) for a new link
+// and sets link.DocID. It returns the hash of the rendering, to be recorded
+// by finishUpload.
+func createDoc(cfg *config.Config, filePath string, title string, link *config.Link) (*gdrive.DocInfo, string, error) {
+	if link.Tabs {
+		// Render and scan first so a broken file doesn't leave an empty doc
+		// behind.
+		contents, err := tabContents(cfg, filePath, link)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to convert markdown: %w", err)
+		}
+		if err := checkSecrets(cfg, filePath, link, tabHTML(contents)...); err != nil {
+			return nil, "", err
+		}
+
+		created, err := docStore.CreateTabbedDoc(title, linkFolderID)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create Google Doc: %w", err)
+		}
+		link.DocID = created.ID
+
+		docInfo, hash, err := pushTabs(cfg, filePath, link, uploadOptions{})
+		if err != nil {
+			docStore.DeleteDoc(created.ID)
+			return nil, "", err
+		}
+		return docInfo, hash, nil
+	}
+
+	if link.Split > 0 {
+		sections, err := renderSections(cfg, filePath, link, link.Split)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to convert markdown: %w", err)
+		}
+		if err := checkSecrets(cfg, filePath, link, sectionHTML(sections)...); err != nil {
+			return nil, "", err
+		}
+
+		folder, err := docStore.CreateFolder(title, linkFolderID)
+		if err != nil {
+			return nil, "", err
+		}
+		link.DocID = folder.ID
+
+		docInfo, hash, err := pushSplit(cfg, filePath, link, uploadOptions{})
+		if err != nil {
+			docStore.TrashDoc(folder.ID)
+			return nil, "", err
+		}
+		return docInfo, hash, nil
+	}
+
+	htmlContent, err := renderFile(cfg, filePath, link)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to convert markdown: %w", err)
+	}
+
+	if err := checkSecrets(cfg, filePath, link, htmlContent); err != nil {
+		return nil, "", err
+	}
+
+	hash, err := renderHash(cfg, filePath, link, htmlContent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	chars, blocks := convert.MeasureHTML(htmlContent)
+	htmlContent, err = stampBanner(cfg, filePath, link, htmlContent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	docInfo, err := docStore.CreateDoc(title, htmlContent, linkFolderID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create Google Doc: %w", err)
+	}
+	link.DocID = docInfo.ID
+	link.PushedSize = &config.ContentSize{Chars: chars, Blocks: blocks}
+
+	return docInfo, hash, nil
+}
+
+func parseTags(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	tags := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q (expected key=value)", v)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/convert"
+)
+
+var lintStrict bool
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [file.md...]",
+	Short: "Check markdown for constructs Google Docs can't represent",
+	Long: `Report raw HTML that is dropped on import, missing images, images
+without alt text, deeply nested lists and skipped heading levels, with
+file:line positions.
+
+Without arguments all linked files are checked. The same check runs
+before every push; with --strict, issues make lint fail and block the
+push.`,
+	RunE: runLint,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Exit with an error when issues are found")
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	files := args
+	if len(files) == 0 {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		files = linkedFiles(cfg)
+		if len(files) == 0 {
+			printWarning("No linked files.")
+			fmt.Println("Use 'docmd lint <file.md>' to check a file that isn't linked.")
+			return nil
+		}
+	}
+
+	total := 0
+	for _, f := range files {
+		if _, err := os.Stat(f); os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", f)
+		}
+		issues, err := convert.LintFile(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+		printIssues(issues)
+		total += len(issues)
+	}
+
+	if total == 0 {
+		printSuccess("No issues found")
+		return nil
+	}
+
+	fmt.Printf("\n%d issue(s) found\n", total)
+	if lintStrict {
+		return fmt.Errorf("lint found %d issue(s)", total)
+	}
+	return nil
+}
+
+// linkedFiles returns the source files of all links, including their
+// member files, without duplicates.
+func linkedFiles(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for key, link := range cfg.Links {
+		for _, f := range append([]string{config.SourcePath(key)}, memberFiles(link)...) {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func printIssues(issues []convert.Issue) {
+	cwd, _ := os.Getwd()
+	for _, issue := range issues {
+		if rel, err := filepath.Rel(cwd, issue.File); err == nil && filepath.IsLocal(rel) {
+			issue.File = rel
+		}
+		printWarning(issue.String())
+	}
+}
+
+// lintBeforePush checks the files of a link before they are uploaded. In
+// strict mode issues, and files that can't be checked, block the push.
+func lintBeforePush(filePath string, link *config.Link, strict bool) error {
+	total := 0
+	for _, f := range append([]string{filePath}, memberFiles(link)...) {
+		issues, err := convert.LintFile(f)
+		if err != nil {
+			if strict {
+				return fmt.Errorf("failed to lint %s: %w", f, err)
+			}
+			printWarning(fmt.Sprintf("Failed to lint %s: %v", f, err))
+			continue
+		}
+		printIssues(issues)
+		total += len(issues)
+	}
+
+	if total > 0 && strict {
+		return fmt.Errorf("lint found %d issue(s); fix them or push without --strict", total)
+	}
+	return nil
+}
@@ -2,23 +2,22 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/ohhmaar/docmd/internal/auth"
 	"github.com/ohhmaar/docmd/internal/config"
-	"github.com/ohhmaar/docmd/internal/convert"
-	"github.com/ohhmaar/docmd/internal/gdrive"
 )
 
 var (
-	pushForce bool
-	pushAll   bool
+	pushForce       bool
+	pushAll         bool
+	pushStrict      bool
+	pushAllowShrink bool
 )
 
 var pushCmd = &cobra.Command{
@@ -26,8 +25,14 @@ var pushCmd = &cobra.Command{
 	Short: "Push local changes to Google Docs",
 	Long: `Sync local markdown changes to the linked Google Doc.
 
-By default, checks for conflicts (remote changes since last sync).
-Use --force to overwrite without checking.`,
+By default, checks for conflicts (remote changes since last sync) and
+asks before a push that would remove most of the doc (more than the
+config's max_shrink share, 50% by default). Use --force to overwrite
+without checking for conflicts, and --allow-shrink to push a shrunk file
+without asking.
+
+The files are linted first (see 'docmd lint'); with --strict any issue
+blocks the push.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runPush,
 }
@@ -36,10 +41,12 @@ func init() {
 	rootCmd.AddCommand(pushCmd)
 	pushCmd.Flags().BoolVarP(&pushForce, "force", "f", false, "Skip conflict check and overwrite")
 	pushCmd.Flags().BoolVarP(&pushAll, "all", "a", false, "Push all linked files")
+	pushCmd.Flags().BoolVar(&pushStrict, "strict", false, "Don't push files with lint issues")
+	pushCmd.Flags().BoolVar(&pushAllowShrink, "allow-shrink", false, "Push without asking when most of the doc would be removed")
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
-	if !auth.TokenExists() {
+	if !authenticated() {
 		printError("Not authenticated!")
 		fmt.Println("Run 'docmd init' first to authenticate with Google.")
 		return fmt.Errorf("not authenticated")
@@ -62,13 +69,12 @@ func runPush(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 	} else if len(args) == 1 {
-		absPath, _ := filepath.Abs(args[0])
-		if _, exists := cfg.GetLink(absPath); !exists {
+		filesToPush = cfg.LinksForFile(args[0])
+		if len(filesToPush) == 0 {
 			printError("File is not linked!")
 			fmt.Println("Use 'docmd link' to link this file first.")
 			return fmt.Errorf("file not linked")
 		}
-		filesToPush = []string{absPath}
 	} else {
 		printError("No file specified!")
 		fmt.Println("Usage: docmd push <file.md>")
@@ -76,9 +82,9 @@ func runPush(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no file specified")
 	}
 
-	for _, filePath := range filesToPush {
-		if err := pushFile(cfg, filePath); err != nil {
-			printError(fmt.Sprintf("Failed to push %s: %v", filepath.Base(filePath), err))
+	for _, key := range filesToPush {
+		if err := pushFile(cfg, key); err != nil {
+			printError(fmt.Sprintf("Failed to push %s: %v", displayName(key, cfg.Links[key]), err))
 			if !pushAll {
 				return err
 			}
@@ -88,16 +94,21 @@ func runPush(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func pushFile(cfg *config.Config, filePath string) error {
-	link, ok := cfg.GetLink(filePath)
+func pushFile(cfg *config.Config, key string) error {
+	link, ok := cfg.GetLink(key)
 	if !ok {
 		return fmt.Errorf("file not linked")
 	}
 
+	filePath := config.SourcePath(key)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return fmt.Errorf("file not found: %s", filePath)
 	}
 
+	if err := lintBeforePush(filePath, link, pushStrict); err != nil {
+		return err
+	}
+
 	if !pushForce {
 		hasConflict, err := checkConflict(link)
 		if err != nil {
@@ -113,19 +124,38 @@ func pushFile(cfg *config.Config, filePath string) error {
 		}
 	}
 
-	fmt.Printf("Syncing %s -> Google Docs...\n", filepath.Base(filePath))
+	fmt.Printf("Syncing %s -> Google Docs...\n", displayName(key, link))
 
-	htmlContent, err := convert.FileToHTML(filePath)
+	remoteChanged, err := backupRemote(cfg, filePath, link)
 	if err != nil {
-		return fmt.Errorf("failed to convert markdown: %w", err)
+		return err
+	}
+
+	opts := uploadOptions{confirm: confirmShrink, overwrite: pushForce || remoteChanged}
+	if pushAllowShrink {
+		opts.confirm = func(string) bool { return true }
 	}
 
-	docInfo, err := gdrive.UpdateDoc(link.DocID, htmlContent)
+	docInfo, hash, err := uploadDoc(cfg, filePath, link, opts)
+	if errors.Is(err, errUnchanged) {
+		if err := cfg.UpdateSyncTime(key, link.LastRevisionID); err != nil {
+			printWarning(fmt.Sprintf("Failed to update sync time: %v", err))
+		}
+		printSuccess("Rendered doc is unchanged; nothing to push.")
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update Google Doc: %w", err)
+		return err
 	}
 
-	if err := cfg.UpdateSyncTime(filePath, docInfo.ModifiedTime.Format(time.RFC3339)); err != nil {
+	if err := finishUpload(cfg, filePath, link, hash); err != nil {
+		printWarning(fmt.Sprintf("Failed to finish document: %v", err))
+	}
+
+	recordRevisions(filePath, link)
+	recordFingerprint(filePath, link)
+
+	if err := cfg.UpdateSyncTime(key, docInfo.ModifiedTime.Format(time.RFC3339)); err != nil {
 		printWarning(fmt.Sprintf("Failed to update sync time: %v", err))
 	}
 
@@ -140,7 +170,7 @@ func checkConflict(link *config.Link) (bool, error) {
 		return false, nil
 	}
 
-	docInfo, err := gdrive.GetDocInfo(link.DocID)
+	docInfo, err := remoteInfo(link)
 	if err != nil {
 		return false, err
 	}
@@ -149,7 +179,7 @@ func checkConflict(link *config.Link) (bool, error) {
 }
 
 func handleConflict(link *config.Link, filePath string) (bool, error) {
-	docInfo, err := gdrive.GetDocInfo(link.DocID)
+	docInfo, err := remoteInfo(link)
 	if err != nil {
 		return false, err
 	}
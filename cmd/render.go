@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/convert"
+	"github.com/ohhmaar/docmd/internal/gdrive"
+	"github.com/ohhmaar/docmd/internal/gitinfo"
+)
+
+var (
+	renderFormat  string
+	renderOutput  string
+	renderVariant string
+	renderTags    []string
+	renderBreaks  bool
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render <file.md> [more.md...]",
+	Short: "Render a file locally as HTML or Word",
+	Long: `Render a file the way 'docmd push' would and write the result to disk,
+without talking to Google.
+
+--format docx writes a Word file with the same headings, lists, tables,
+code blocks and images as the doc, using the page settings from the
+config, the file's front matter and its link.
+
+A linked file is rendered with the settings of its link (or of the link
+given by --variant). Several files are combined in order, like a book.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRender,
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+	renderCmd.Flags().StringVar(&renderFormat, "format", "html", "Output format: html or docx")
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "Output file (default: the input name with the format's extension)")
+	renderCmd.Flags().StringVar(&renderVariant, "variant", "", "Use the settings of this variant's link")
+	renderCmd.Flags().StringArrayVar(&renderTags, "tag", nil, "Active tag for conditional blocks as key=value (repeatable)")
+	renderCmd.Flags().BoolVar(&renderBreaks, "page-breaks", false, "Start every file of a book on a new page")
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	filePath := args[0]
+
+	if renderFormat != "html" && renderFormat != "docx" {
+		return fmt.Errorf("unknown format %q: use html or docx", renderFormat)
+	}
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return fmt.Errorf("file not found: %s", filePath)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	absPath, _ := filepath.Abs(filePath)
+	link := &config.Link{}
+	if existing, ok := cfg.GetLink(config.LinkKey(absPath, renderVariant)); ok {
+		copied := *existing
+		link = &copied
+	} else if renderVariant != "" {
+		return fmt.Errorf("%s has no variant %q", filepath.Base(filePath), renderVariant)
+	}
+
+	if cmd.Flags().Changed("tag") {
+		tags, err := parseTags(renderTags)
+		if err != nil {
+			return err
+		}
+		link.Tags = tags
+	}
+	if renderBreaks {
+		link.PageBreaks = true
+	}
+	for _, f := range args[1:] {
+		abs, _ := filepath.Abs(f)
+		link.BookFiles = append(link.BookFiles, abs)
+	}
+
+	htmlContent, err := renderFile(cfg, filePath, link)
+	if err != nil {
+		return fmt.Errorf("failed to convert markdown: %w", err)
+	}
+	htmlContent, err = stampBanner(cfg, filePath, link, htmlContent)
+	if err != nil {
+		return err
+	}
+
+	output := []byte(htmlContent)
+	if renderFormat == "docx" {
+		setup, err := pageSetup(cfg, filePath, link)
+		if err != nil {
+			return err
+		}
+		output, err = convert.HTMLToDocx(htmlContent, docxPage(setup))
+		if err != nil {
+			return fmt.Errorf("failed to create docx: %w", err)
+		}
+	}
+
+	outPath := renderOutput
+	if outPath == "" {
+		outPath = strings.TrimSuffix(filePath, filepath.Ext(filePath)) + "." + renderFormat
+	}
+	if outAbs, _ := filepath.Abs(outPath); outAbs == absPath {
+		return fmt.Errorf("output would overwrite %s; choose another with --output", filePath)
+	}
+	if err := os.WriteFile(outPath, output, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+
+	printSuccess(fmt.Sprintf("Rendered %s", outPath))
+	return nil
+}
+
+func docxPage(setup *gdrive.PageSetup) convert.DocxPage {
+	if setup == nil {
+		return convert.DocxPage{}
+	}
+	page := convert.DocxPage{
+		Width:     setup.Width,
+		Height:    setup.Height,
+		Landscape: setup.Landscape,
+	}
+	if m := setup.Margins; m != nil {
+		page.Margins = &[4]float64{m.Top, m.Right, m.Bottom, m.Left}
+	}
+	return page
+}
+
+func renderFile(cfg *config.Config, filePath string, link *config.Link) (string, error) {
+	opts := convertOptions(cfg, filePath, link)
+	if len(link.BookFiles) > 0 {
+		opts.BaseDir = filepath.Dir(filePath)
+		return convert.BookToHTML(append([]string{filePath}, link.BookFiles...), opts)
+	}
+	return convert.FileToHTML(filePath, opts)
+}
+
+// memberFiles returns the files other than the linked one that make up the
+// link's doc.
+func memberFiles(link *config.Link) []string {
+	return append(append([]string(nil), link.TabFiles...), link.BookFiles...)
+}
+
+// uploadDoc replaces the content of the link's doc with the rendered file
+// and returns the hash of the rendering, to be recorded by finishUpload. It
+// returns errUnchanged without uploading when the rendering matches the
+// last push.
+func uploadDoc(cfg *config.Config, filePath string, link *config.Link, opts uploadOptions) (*gdrive.DocInfo, string, error) {
+	switch {
+	case link.Tabs:
+		return pushTabs(cfg, filePath, link, opts)
+	case link.Split > 0:
+		return pushSplit(cfg, filePath, link, opts)
+	}
+
+	htmlContent, err := renderFile(cfg, filePath, link)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to convert markdown: %w", err)
+	}
+
+	hash, err := checkRendered(cfg, filePath, link, opts, htmlContent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := checkSecrets(cfg, filePath, link, htmlContent); err != nil {
+		return nil, "", err
+	}
+
+	size, err := guardShrink(cfg, link, opts.confirm, htmlContent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	htmlContent, err = stampBanner(cfg, filePath, link, htmlContent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	docInfo, err := docStore.UpdateDoc(link.DocID, htmlContent)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to update Google Doc: %w", err)
+	}
+	link.PushedSize = size
+
+	return docInfo, hash, nil
+}
+
+func convertOptions(cfg *config.Config, filePath string, link *config.Link) convert.Options {
+	opts := convert.Options{
+		Tags:           link.Tags,
+		HideCode:       link.HideCode,
+		HideOutputs:    link.HideOutputs,
+		VaultRoot:      link.VaultRoot,
+		NumberHeadings: link.NumberHeadings,
+		NumberFrom:     link.NumberFrom,
+		PageBreaks:     link.PageBreaks,
+	}
+
+	if opts.VaultRoot == "" {
+		opts.VaultRoot = cfg.VaultRoot
+	}
+	if opts.VaultRoot == "" {
+		opts.VaultRoot = convert.FindVaultRoot(filePath)
+	}
+
+	opts.Banner = linkBanner(cfg, filePath, link)
+
+	for ext, conv := range cfg.Converters {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		opts.Formats = append(opts.Formats, convert.ExternalFormat{
+			Extension: ext,
+			Command:   conv.Command,
+			Timeout:   time.Duration(conv.TimeoutSeconds) * time.Second,
+		})
+	}
+
+	opts.ResolveLink = func(target string) (string, bool) {
+		if other, ok := fileLink(cfg, target); ok {
+			return other.DocURL, true
+		}
+		return "", false
+	}
+
+	return opts
+}
+
+// fileLink returns the link a link to filePath resolves to: its default
+// link, or its first variant when it is only linked as variants.
+func fileLink(cfg *config.Config, filePath string) (*config.Link, bool) {
+	keys := cfg.LinksForFile(filePath)
+	if len(keys) == 0 {
+		return nil, false
+	}
+	return cfg.Links[keys[0]], true
+}
+
+// linkBanner returns the banner of the link's renderings, or nil when it
+// has none. Its commit, branch and push time are left out, to be set by
+// stampBanner.
+func linkBanner(cfg *config.Config, filePath string, link *config.Link) *convert.Banner {
+	if cfg.Banner == nil || !cfg.Banner.Enabled || link.NoBanner {
+		return nil
+	}
+	return newBanner(cfg.Banner, filePath)
+}
+
+// stampBanner sets the commit, branch and current time in the banner of a
+// rendering of the file, right before it is uploaded or written.
+func stampBanner(cfg *config.Config, filePath string, link *config.Link, htmlContent string) (string, error) {
+	banner := linkBanner(cfg, filePath, link)
+	if banner == nil {
+		return htmlContent, nil
+	}
+
+	info := gitinfo.Lookup(filePath)
+	data := banner.Data
+	data.Commit = info.Commit
+	data.Branch = info.Branch
+	data.PushedAt = time.Now()
+
+	return banner.Stamp(htmlContent, data)
+}
+
+func newBanner(bc *config.BannerConfig, filePath string) *convert.Banner {
+	info := gitinfo.Lookup(filePath)
+
+	banner := &convert.Banner{
+		Header: bc.Header,
+		Footer: bc.Footer,
+		Data: convert.BannerData{
+			Repo: info.Repo,
+			Path: info.Path,
+		},
+	}
+
+	if banner.Header == "" {
+		banner.Header = convert.DefaultBannerHeader
+	}
+	if banner.Footer == "" {
+		banner.Footer = convert.DefaultBannerFooter
+	}
+
+	return banner
+}
+
+func displayName(key string, link *config.Link) string {
+	name := filepath.Base(config.SourcePath(key))
+	if link.Variant != "" {
+		name += " [" + link.Variant + "]"
+	}
+	return name
+}
+
+// finishUpload runs postUpload and records the rendering of the upload
+// once it succeeded. On failure the recorded rendering is cleared, so the
+// next push uploads again instead of reporting the doc as unchanged.
+func finishUpload(cfg *config.Config, filePath string, link *config.Link, hash string) error {
+	if err := postUpload(cfg, filePath, link); err != nil {
+		link.RenderedHash = ""
+		return err
+	}
+	setRendered(link, hash)
+	return nil
+}
+
+// postUpload applies changes that can only be made through the Docs API
+// once the HTML has been imported.
+func postUpload(cfg *config.Config, filePath string, link *config.Link) error {
+	setup, err := pageSetup(cfg, filePath, link)
+	if err != nil {
+		return err
+	}
+
+	for _, docID := range linkDocIDs(link) {
+		if cfg.PersonChips || link.PersonChips {
+			if _, err := docStore.InsertPersonChips(docID); err != nil {
+				return err
+			}
+		}
+
+		if setup != nil {
+			if err := docStore.ApplyPageSetup(docID, setup); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pageSetup merges the page settings of the config, the file's front matter
+// and the link, in increasing order of precedence. It returns nil when none
+// are set.
+func pageSetup(cfg *config.Config, filePath string, link *config.Link) (*gdrive.PageSetup, error) {
+	var page config.PageConfig
+	set := false
+
+	merge := func(p *config.PageConfig) {
+		if p == nil {
+			return
+		}
+		if p.Size != "" {
+			page.Size = p.Size
+			set = true
+		}
+		if p.Orientation != "" {
+			page.Orientation = p.Orientation
+			set = true
+		}
+		if p.Margins != "" {
+			page.Margins = p.Margins
+			set = true
+		}
+		if p.Pageless {
+			page.Pageless = true
+			set = true
+		}
+	}
+
+	merge(cfg.Page)
+
+	if fm, err := convert.ReadFrontMatter(filePath); err == nil {
+		fromFile := &config.PageConfig{
+			Size:        fm["page_size"],
+			Orientation: fm["page_orientation"],
+			Margins:     fm["page_margins"],
+		}
+		fromFile.Pageless, _ = fm.Bool("pageless")
+		merge(fromFile)
+	}
+
+	merge(link.Page)
+
+	if !set {
+		return nil, nil
+	}
+
+	return gdrive.NewPageSetup(page.Size, page.Orientation, page.Margins, page.Pageless)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/gdrive"
+)
+
+var errUnchanged = errors.New("rendered doc is unchanged")
+
+// uploadOptions controls an upload. confirm is asked before an upload that
+// removes most of the doc. overwrite uploads even when the rendering
+// matches the last push, as needed when the doc was edited since.
+type uploadOptions struct {
+	confirm   confirmFunc
+	overwrite bool
+}
+
+// renderHash identifies what an upload of the rendered contents would
+// produce, including the settings applied to the doc after the upload. The
+// contents are hashed before stampBanner sets the push time.
+func renderHash(cfg *config.Config, filePath string, link *config.Link, contents ...string) (string, error) {
+	setup, err := pageSetup(cfg, filePath, link)
+	if err != nil {
+		return "", err
+	}
+	settings, err := json.Marshal(struct {
+		Page        *gdrive.PageSetup `json:"page"`
+		PersonChips bool              `json:"person_chips"`
+	}{setup, cfg.PersonChips || link.PersonChips})
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write(settings)
+	for _, content := range contents {
+		h.Write([]byte{0})
+		h.Write([]byte(content))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkRendered returns errUnchanged when the rendered contents match the
+// last push and the doc doesn't need to be overwritten. Otherwise it
+// returns the hash for finishUpload to record.
+func checkRendered(cfg *config.Config, filePath string, link *config.Link, opts uploadOptions, contents ...string) (string, error) {
+	hash, err := renderHash(cfg, filePath, link, contents...)
+	if err != nil {
+		return "", err
+	}
+	if !opts.overwrite && hash == link.RenderedHash {
+		return "", errUnchanged
+	}
+	return hash, nil
+}
+
+// setRendered records the rendering of a successful upload.
+func setRendered(link *config.Link, hash string) {
+	link.RenderedHash = hash
+	link.RenderedWith = strings.TrimSpace(version)
+}
+
+// renderingChanged renders the file as a push would and describes how the
+// result differs from the last push, or returns "" when it doesn't.
+func renderingChanged(cfg *config.Config, filePath string, link *config.Link) (string, error) {
+	if link.RenderedHash == "" {
+		return "", nil
+	}
+
+	var contents []string
+	switch {
+	case link.Tabs:
+		tabs, err := tabContents(cfg, filePath, link)
+		if err != nil {
+			return "", err
+		}
+		contents = tabHTML(tabs)
+	case link.Split > 0:
+		sections, err := renderSections(cfg, filePath, link, link.Split)
+		if err != nil {
+			return "", err
+		}
+		contents = sectionHTML(sections)
+	default:
+		htmlContent, err := renderFile(cfg, filePath, link)
+		if err != nil {
+			return "", err
+		}
+		contents = []string{htmlContent}
+	}
+
+	hash, err := renderHash(cfg, filePath, link, contents...)
+	if err != nil || hash == link.RenderedHash {
+		return "", err
+	}
+
+	current := strings.TrimSpace(version)
+	if link.RenderedWith != "" && link.RenderedWith != current {
+		return fmt.Sprintf("docmd %s renders it differently than %s", current, link.RenderedWith), nil
+	}
+	return "the config, or a file or image it includes, changed", nil
+}
@@ -5,8 +5,16 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ohhmaar/docmd/internal/auth"
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/store"
 )
 
+// docStore holds the docs of linked files. It is opened from the config
+// before each command runs.
+var docStore store.Store = store.Drive{}
+
 var rootCmd = &cobra.Command{
 	Use:   "docmd",
 	Short: "Sync markdown files to Google Docs",
@@ -14,6 +22,7 @@ var rootCmd = &cobra.Command{
 in sync with Google Docs.
 
 Write in your favorite editor, sync to Google Docs for sharing.`,
+	PersistentPreRunE: openStore,
 }
 
 func Execute() error {
@@ -24,6 +33,31 @@ func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 }
 
+// openStore selects the doc store configured under "store". Config errors
+// are left for the command to report.
+func openStore(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+
+	s, err := store.Open(cfg.Store)
+	if err != nil {
+		return err
+	}
+	docStore = s
+	return nil
+}
+
+// authenticated reports whether the doc store can be used. The local store
+// needs no Google account.
+func authenticated() bool {
+	if _, ok := docStore.(*store.FS); ok {
+		return true
+	}
+	return auth.TokenExists()
+}
+
 func printSuccess(msg string) {
 	fmt.Printf("OK: %s\n", msg)
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/secrets"
+)
+
+// checkSecrets refuses rendered content that looks like it contains
+// credentials. A match can be allowed by adding it, or its fingerprint, to
+// secrets.allow in the config.
+func checkSecrets(cfg *config.Config, filePath string, link *config.Link, contents ...string) error {
+	rules := secrets.DefaultRules
+	var allow []string
+	if sc := cfg.Secrets; sc != nil {
+		custom, err := secrets.CompileRules(sc.Patterns)
+		if err != nil {
+			return err
+		}
+		rules = append(append([]secrets.Rule(nil), rules...), custom...)
+		allow = sc.Allow
+	}
+
+	var findings []secrets.Finding
+	seen := make(map[string]bool)
+	for _, content := range contents {
+		for _, f := range secrets.ScanHTML(content, rules, allow) {
+			if !seen[f.Match] {
+				seen[f.Match] = true
+				findings = append(findings, f)
+			}
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	sources := append([]string{filePath}, memberFiles(link)...)
+	for _, f := range findings {
+		printError(fmt.Sprintf("%s: possible %s: %s (%s)",
+			locateMatch(f.Match, sources), f.Rule, f.Masked(), secrets.Fingerprint(f.Match)))
+	}
+	fmt.Println("Remove them, or add a fingerprint to \"secrets.allow\" in the config if it is safe to share.")
+
+	return fmt.Errorf("refusing to upload: found %d possible secret(s)", len(findings))
+}
+
+// locateMatch returns file:line of the first source file containing match,
+// or the name of the first file when the match comes from elsewhere (an
+// embedded snippet, for example).
+func locateMatch(match string, files []string) string {
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if i := strings.Index(string(data), match); i >= 0 {
+			return fmt.Sprintf("%s:%d", filepath.Base(f), 1+strings.Count(string(data[:i]), "\n"))
+		}
+	}
+	return filepath.Base(files[0])
+}
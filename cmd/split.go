@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/convert"
+	"github.com/ohhmaar/docmd/internal/gdrive"
+)
+
+type renderedSection struct {
+	Title string
+	HTML  string
+}
+
+// renderSections cuts a markdown file at headings of the given level and
+// renders every section on its own. Content before the first heading is
+// titled after the file.
+func renderSections(cfg *config.Config, filePath string, link *config.Link, level int) ([]renderedSection, error) {
+	if convert.FormatFor(filePath).Name() != "markdown" {
+		return nil, fmt.Errorf("splitting by heading is only supported for markdown files")
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	opts := convertOptions(cfg, filePath, link)
+	opts.BaseDir = filepath.Dir(filePath)
+
+	var sections []renderedSection
+	for _, section := range convert.SplitSections(data, level) {
+		htmlContent, err := convert.MarkdownToHTML(section.Source, opts)
+		if err != nil {
+			return nil, err
+		}
+		title := section.Title
+		if title == "" {
+			title = fileTitle(filePath)
+		}
+		sections = append(sections, renderedSection{Title: title, HTML: htmlContent})
+	}
+
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("file has no content")
+	}
+
+	return sections, nil
+}
+
+func sectionHTML(sections []renderedSection) []string {
+	htmls := make([]string, len(sections))
+	for i, s := range sections {
+		htmls[i] = s.HTML
+	}
+	return htmls
+}
+
+// pushSplit updates the section docs of a split link. Sections are matched
+// to their docs by title; the remaining ones are matched in order, which
+// keeps a renamed section in its doc. Docs of removed sections are moved to
+// the trash.
+func pushSplit(cfg *config.Config, filePath string, link *config.Link, opts uploadOptions) (*gdrive.DocInfo, string, error) {
+	sections, err := renderSections(cfg, filePath, link, link.Split)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to convert markdown: %w", err)
+	}
+
+	hash, err := checkRendered(cfg, filePath, link, opts, sectionHTML(sections)...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := checkSecrets(cfg, filePath, link, sectionHTML(sections)...); err != nil {
+		return nil, "", err
+	}
+
+	size, err := guardShrink(cfg, link, opts.confirm, sectionHTML(sections)...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i := range sections {
+		if sections[i].HTML, err = stampBanner(cfg, filePath, link, sections[i].HTML); err != nil {
+			return nil, "", err
+		}
+	}
+
+	titles := make([]string, len(sections))
+	for i, s := range sections {
+		titles[i] = s.Title
+	}
+	matched, removed := matchSections(link.Sections, titles)
+
+	var updated []*config.SectionLink
+
+	// fail records the docs handled so far along with the remaining ones,
+	// so the next push reuses the docs created before the failure instead
+	// of creating them again.
+	fail := func(i int, err error) (*gdrive.DocInfo, string, error) {
+		sections := append([]*config.SectionLink(nil), updated...)
+		for _, doc := range matched[i:] {
+			if doc != nil {
+				sections = append(sections, doc)
+			}
+		}
+		link.Sections = append(sections, removed...)
+		if saveErr := cfg.Save(); saveErr != nil {
+			printWarning(fmt.Sprintf("Failed to save section docs: %v", saveErr))
+		}
+		return nil, "", err
+	}
+
+	for i, section := range sections {
+		doc := matched[i]
+
+		if doc == nil {
+			created, err := docStore.CreateDoc(section.Title, section.HTML, link.DocID)
+			if err != nil {
+				return fail(i, fmt.Errorf("failed to create doc for %q: %w", section.Title, err))
+			}
+			printInfo(fmt.Sprintf("Created doc for section %q", section.Title))
+			updated = append(updated, &config.SectionLink{Title: section.Title, DocID: created.ID, DocURL: created.URL})
+			continue
+		}
+
+		if _, err := docStore.UpdateDoc(doc.DocID, section.HTML); err != nil {
+			return fail(i, fmt.Errorf("failed to update doc for %q: %w", section.Title, err))
+		}
+		if doc.Title != section.Title {
+			if err := docStore.RenameDoc(doc.DocID, section.Title); err != nil {
+				return fail(i, err)
+			}
+			printInfo(fmt.Sprintf("Renamed section %q to %q", doc.Title, section.Title))
+			doc.Title = section.Title
+		}
+		updated = append(updated, doc)
+	}
+
+	for _, doc := range removed {
+		if err := docStore.TrashDoc(doc.DocID); err != nil {
+			printWarning(fmt.Sprintf("Failed to remove doc for section %q: %v", doc.Title, err))
+			continue
+		}
+		printInfo(fmt.Sprintf("Moved doc for removed section %q to the trash", doc.Title))
+	}
+
+	link.Sections = updated
+	link.PushedSize = size
+
+	docInfo, err := docStore.GetDocInfo(link.DocID)
+	if err != nil {
+		return nil, "", err
+	}
+	return docInfo, hash, nil
+}
+
+// matchSections pairs section titles with existing section docs. matched
+// has an entry per title, nil where a new doc is needed.
+func matchSections(existing []*config.SectionLink, titles []string) (matched []*config.SectionLink, removed []*config.SectionLink) {
+	matched = make([]*config.SectionLink, len(titles))
+	used := make([]bool, len(existing))
+
+	for i, title := range titles {
+		for j, doc := range existing {
+			if !used[j] && doc.Title == title {
+				matched[i] = doc
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	j := 0
+	for i := range titles {
+		if matched[i] != nil {
+			continue
+		}
+		for j < len(existing) && used[j] {
+			j++
+		}
+		if j == len(existing) {
+			break
+		}
+		matched[i] = existing[j]
+		used[j] = true
+	}
+
+	for j, doc := range existing {
+		if !used[j] {
+			removed = append(removed, doc)
+		}
+	}
+
+	return matched, removed
+}
+
+// linkDocIDs returns the docs a link writes to.
+func linkDocIDs(link *config.Link) []string {
+	if link.Split == 0 {
+		return []string{link.DocID}
+	}
+	ids := make([]string, len(link.Sections))
+	for i, s := range link.Sections {
+		ids[i] = s.DocID
+	}
+	return ids
+}
+
+// remoteInfo returns the info of the link's doc, or of its most recently
+// modified section doc for a split link.
+func remoteInfo(link *config.Link) (*gdrive.DocInfo, error) {
+	var latest *gdrive.DocInfo
+	for _, id := range linkDocIDs(link) {
+		info, err := docStore.GetDocInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil || info.ModifiedTime.After(latest.ModifiedTime) {
+			latest = info
+		}
+	}
+	if latest == nil {
+		return docStore.GetDocInfo(link.DocID)
+	}
+	return latest, nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ohhmaar/docmd/internal/config"
+)
+
+func TestMatchSections(t *testing.T) {
+	a := &config.SectionLink{Title: "A", DocID: "a"}
+	b := &config.SectionLink{Title: "B", DocID: "b"}
+	c := &config.SectionLink{Title: "C", DocID: "c"}
+
+	tests := []struct {
+		name        string
+		existing    []*config.SectionLink
+		titles      []string
+		wantMatched []string
+		wantRemoved []string
+	}{
+		{"first push", nil, []string{"A", "B"}, []string{"", ""}, nil},
+		{"same sections", []*config.SectionLink{a, b}, []string{"A", "B"}, []string{"a", "b"}, nil},
+		{"reordered", []*config.SectionLink{a, b}, []string{"B", "A"}, []string{"b", "a"}, nil},
+		{"renamed keeps its doc", []*config.SectionLink{a, b}, []string{"A", "B2"}, []string{"a", "b"}, nil},
+		{"added section", []*config.SectionLink{a}, []string{"A", "New"}, []string{"a", ""}, nil},
+		{"removed section", []*config.SectionLink{a, b, c}, []string{"A", "C"}, []string{"a", "c"}, []string{"b"}},
+		{"renamed before a match", []*config.SectionLink{a, b}, []string{"X", "B", "Y"}, []string{"a", "b", ""}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, removed := matchSections(tt.existing, tt.titles)
+
+			if len(matched) != len(tt.wantMatched) {
+				t.Fatalf("got %d matches, want %d", len(matched), len(tt.wantMatched))
+			}
+			for i, want := range tt.wantMatched {
+				got := ""
+				if matched[i] != nil {
+					got = matched[i].DocID
+				}
+				if got != want {
+					t.Errorf("section %q matched %q, want %q", tt.titles[i], got, want)
+				}
+			}
+
+			if len(removed) != len(tt.wantRemoved) {
+				t.Fatalf("got %d removed, want %d", len(removed), len(tt.wantRemoved))
+			}
+			for i, want := range tt.wantRemoved {
+				if removed[i].DocID != want {
+					t.Errorf("removed %q, want %q", removed[i].DocID, want)
+				}
+			}
+		})
+	}
+}
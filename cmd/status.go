@@ -7,9 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/ohhmaar/docmd/internal/auth"
 	"github.com/ohhmaar/docmd/internal/config"
-	"github.com/ohhmaar/docmd/internal/gdrive"
 )
 
 var statusCmd = &cobra.Command{
@@ -24,7 +22,7 @@ func init() {
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	if !auth.TokenExists() {
+	if !authenticated() {
 		printError("Not authenticated!")
 		fmt.Println("Run 'docmd init' first to authenticate with Google.")
 		return fmt.Errorf("not authenticated")
@@ -44,7 +42,8 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Println("Linked files:")
 	fmt.Println()
 
-	for filePath, link := range cfg.Links {
+	for key, link := range cfg.Links {
+		filePath := config.SourcePath(key)
 		displayPath := filePath
 		if cwd, err := os.Getwd(); err == nil {
 			if rel, err := filepath.Rel(cwd, filePath); err == nil && !filepath.IsAbs(rel) {
@@ -52,10 +51,17 @@ func runStatus(cmd *cobra.Command, args []string) error {
 			}
 		}
 
+		if link.Variant != "" {
+			displayPath += " [" + link.Variant + "]"
+		}
+
 		fmt.Printf("  %s\n", displayPath)
 		fmt.Printf("    -> %s\n", link.DocURL)
+		if link.Split > 0 {
+			fmt.Printf("    Sections: %d doc(s)\n", len(link.Sections))
+		}
 
-		status := getFileStatus(filePath, link, cfg)
+		status := getFileStatus(key, link, cfg)
 		fmt.Printf("    Status: %s\n", status)
 
 		if !link.LastSync.IsZero() {
@@ -69,16 +75,16 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func getFileStatus(filePath string, link *config.Link, cfg *config.Config) string {
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+func getFileStatus(key string, link *config.Link, cfg *config.Config) string {
+	if _, err := os.Stat(config.SourcePath(key)); os.IsNotExist(err) {
 		return "Local file missing"
 	}
 
-	if !gdrive.DocExists(link.DocID) {
+	if !docStore.DocExists(link.DocID) {
 		return "Google Doc not found"
 	}
 
-	hasLocalChanges, err := cfg.HasLocalChanges(filePath)
+	hasLocalChanges, err := cfg.HasLocalChanges(key)
 	if err != nil {
 		return "Unknown (could not check)"
 	}
@@ -87,5 +93,13 @@ func getFileStatus(filePath string, link *config.Link, cfg *config.Config) strin
 		return "Local changes pending"
 	}
 
+	reason, err := renderingChanged(cfg, config.SourcePath(key), link)
+	if err != nil {
+		return "In sync (could not render to compare)"
+	}
+	if reason != "" {
+		return "Rendering changed: " + reason + "; push to update"
+	}
+
 	return "In sync"
 }
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/gdrive"
+)
+
+// pushTabs renders the tabs of a tabbed link and syncs them to its doc,
+// recording the tab IDs on the link.
+func pushTabs(cfg *config.Config, filePath string, link *config.Link, opts uploadOptions) (*gdrive.DocInfo, string, error) {
+	contents, err := tabContents(cfg, filePath, link)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to convert markdown: %w", err)
+	}
+
+	hash, err := checkRendered(cfg, filePath, link, opts, tabHTML(contents)...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := checkSecrets(cfg, filePath, link, tabHTML(contents)...); err != nil {
+		return nil, "", err
+	}
+
+	size, err := guardShrink(cfg, link, opts.confirm, tabHTML(contents)...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Tab files are rendered with their own banner.
+	files := append([]string{filePath}, link.TabFiles...)
+	for i := range contents {
+		f := filePath
+		if len(link.TabFiles) > 0 {
+			f = files[i]
+		}
+		if contents[i].HTML, err = stampBanner(cfg, f, link, contents[i].HTML); err != nil {
+			return nil, "", err
+		}
+	}
+
+	result, err := docStore.SyncTabs(link.DocID, link.TabIDs, contents)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to update Google Doc tabs: %w", err)
+	}
+	link.TabIDs = result.TabIDs
+	link.PushedSize = size
+
+	if result.SkippedImages > 0 {
+		printWarning(fmt.Sprintf("%d image(s) could not be copied into tabs", result.SkippedImages))
+	}
+
+	docInfo, err := docStore.GetDocInfo(link.DocID)
+	if err != nil {
+		return nil, "", err
+	}
+	return docInfo, hash, nil
+}
+
+// tabContents renders one tab per file when the link lists tab files, and
+// one tab per H1 section of the file otherwise.
+func tabContents(cfg *config.Config, filePath string, link *config.Link) ([]gdrive.TabContent, error) {
+	if len(link.TabFiles) > 0 {
+		var contents []gdrive.TabContent
+		for _, f := range append([]string{filePath}, link.TabFiles...) {
+			htmlContent, err := renderFile(cfg, f, link)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filepath.Base(f), err)
+			}
+			contents = append(contents, gdrive.TabContent{Title: fileTitle(f), HTML: htmlContent})
+		}
+		return contents, nil
+	}
+
+	sections, err := renderSections(cfg, filePath, link, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]gdrive.TabContent, len(sections))
+	for i, s := range sections {
+		contents[i] = gdrive.TabContent{Title: s.Title, HTML: s.HTML}
+	}
+	return contents, nil
+}
+
+func tabHTML(contents []gdrive.TabContent) []string {
+	htmls := make([]string, len(contents))
+	for i, c := range contents {
+		htmls[i] = c.HTML
+	}
+	return htmls
+}
+
+func fileTitle(filePath string) string {
+	base := filepath.Base(filePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -4,19 +4,17 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/ohhmaar/docmd/internal/auth"
 	"github.com/ohhmaar/docmd/internal/config"
-	"github.com/ohhmaar/docmd/internal/gdrive"
 )
 
 var (
-	unlinkDelete bool
-	unlinkYes    bool
+	unlinkDelete  bool
+	unlinkYes     bool
+	unlinkVariant string
 )
 
 var unlinkCmd = &cobra.Command{
@@ -34,12 +32,13 @@ func init() {
 	rootCmd.AddCommand(unlinkCmd)
 	unlinkCmd.Flags().BoolVarP(&unlinkDelete, "delete", "d", false, "Also delete the Google Doc")
 	unlinkCmd.Flags().BoolVarP(&unlinkYes, "yes", "y", false, "Skip confirmation prompt")
+	unlinkCmd.Flags().StringVar(&unlinkVariant, "variant", "", "Unlink the named variant instead of the default link")
 }
 
 func runUnlink(cmd *cobra.Command, args []string) error {
 	filePath := args[0]
 
-	if !auth.TokenExists() {
+	if !authenticated() {
 		printError("Not authenticated!")
 		fmt.Println("Run 'docmd init' first to authenticate with Google.")
 		return fmt.Errorf("not authenticated")
@@ -50,15 +49,15 @@ func runUnlink(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	absPath, _ := filepath.Abs(filePath)
-	link, exists := cfg.GetLink(absPath)
+	key := config.LinkKey(filePath, unlinkVariant)
+	link, exists := cfg.GetLink(key)
 	if !exists {
 		printWarning("File is not linked.")
 		return nil
 	}
 
 	if !unlinkYes {
-		fmt.Printf("Unlink %s from Google Docs?\n", filepath.Base(filePath))
+		fmt.Printf("Unlink %s from Google Docs?\n", displayName(key, link))
 		if unlinkDelete {
 			printWarning("The Google Doc WILL be deleted!")
 		} else {
@@ -79,7 +78,7 @@ func runUnlink(cmd *cobra.Command, args []string) error {
 
 	if unlinkDelete {
 		fmt.Println("Deleting Google Doc...")
-		if err := gdrive.DeleteDoc(link.DocID); err != nil {
+		if err := docStore.DeleteDoc(link.DocID); err != nil {
 			printWarning(fmt.Sprintf("Failed to delete Google Doc: %v", err))
 			fmt.Println("The link will still be removed.")
 		} else {
@@ -87,11 +86,11 @@ func runUnlink(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := cfg.RemoveLink(absPath); err != nil {
+	if err := cfg.RemoveLink(key); err != nil {
 		return fmt.Errorf("failed to remove link: %w", err)
 	}
 
-	printSuccess(fmt.Sprintf("Unlinked %s", filepath.Base(filePath)))
+	printSuccess(fmt.Sprintf("Unlinked %s", displayName(key, link)))
 
 	return nil
 }
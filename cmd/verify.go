@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/gdrive"
+	"github.com/ohhmaar/docmd/internal/gitinfo"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [file.md...]",
+	Short: "Check that linked docs still contain what was pushed",
+	Long: `Compare linked docs with the local state, for all links or those of the
+given files. Each push stores a fingerprint on the doc: the hash of the
+rendered content and of the doc's text, the source path within its
+repository, the docmd version and the machine. verify reports docs that
+were edited since the push, pushed from another file or machine, or never
+fingerprinted, and files with changes that weren't pushed.
+
+It exits with an error when any doc differs, so it can guard scripts and
+CI jobs before they overwrite anything.`,
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// recordFingerprint stores the fingerprint of a push on each doc of the
+// link.
+func recordFingerprint(filePath string, link *config.Link) {
+	for _, docID := range linkDocIDs(link) {
+		export, err := exportHash(docID)
+		if err != nil {
+			printWarning(fmt.Sprintf("Failed to record the push fingerprint: %v", err))
+			continue
+		}
+
+		fp := &gdrive.Fingerprint{
+			Hash:     link.RenderedHash,
+			Export:   export,
+			Source:   fingerprintSource(filePath),
+			Version:  strings.TrimSpace(version),
+			Machine:  machineName(),
+			PushedAt: time.Now(),
+		}
+		if err := docStore.SetFingerprint(docID, fp); err != nil {
+			printWarning(fmt.Sprintf("Failed to record the push fingerprint: %v", err))
+		}
+	}
+}
+
+// exportHash hashes the text export of a doc, which changes with any edit
+// of its content.
+func exportHash(docID string) (string, error) {
+	text, err := docStore.ExportDoc(docID, "text/plain")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(text)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// fingerprintSource identifies a file across checkouts by its path in its
+// repository, or by its absolute path outside of one.
+func fingerprintSource(filePath string) string {
+	if info := gitinfo.Lookup(filePath); info.Root != "" {
+		return info.Path
+	}
+	return filepath.ToSlash(filePath)
+}
+
+// sameSource reports whether two fingerprint sources name the same file.
+// One may end the other, as long sources are cut to their last directories
+// and older fingerprints hold absolute paths.
+func sameSource(a, b string) bool {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasSuffix(a, "/"+b)
+}
+
+func machineName() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	if !authenticated() {
+		printError("Not authenticated!")
+		fmt.Println("Run 'docmd init' first to authenticate with Google.")
+		return fmt.Errorf("not authenticated")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var keys []string
+	if len(args) == 0 {
+		for key := range cfg.Links {
+			keys = append(keys, key)
+		}
+	}
+	for _, arg := range args {
+		linked := cfg.LinksForFile(arg)
+		if len(linked) == 0 {
+			return fmt.Errorf("file not linked: %s", arg)
+		}
+		keys = append(keys, linked...)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		printWarning("No linked files.")
+		return nil
+	}
+
+	differing := 0
+	for _, key := range keys {
+		link := cfg.Links[key]
+		problems := verifyLink(cfg, key, link)
+		if len(problems) == 0 {
+			printSuccess(displayName(key, link) + " matches its doc")
+			continue
+		}
+
+		differing++
+		printWarning(displayName(key, link) + ":")
+		for _, p := range problems {
+			fmt.Printf("  - %s\n", p)
+		}
+	}
+
+	if differing > 0 {
+		return fmt.Errorf("%d of %d linked file(s) differ from their docs", differing, len(keys))
+	}
+	return nil
+}
+
+// verifyLink compares the docs of a link with their fingerprints and the
+// local state, and describes each difference.
+func verifyLink(cfg *config.Config, key string, link *config.Link) []string {
+	var problems []string
+	filePath := config.SourcePath(key)
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		problems = append(problems, "the local file is missing")
+	} else if changed, err := cfg.HasLocalChanges(key); err == nil && changed {
+		problems = append(problems, "the file has changes that weren't pushed")
+	} else if reason, err := renderingChanged(cfg, filePath, link); err == nil && reason != "" {
+		problems = append(problems, "the rendering changed since the last push: "+reason)
+	}
+
+	for _, docID := range linkDocIDs(link) {
+		problems = append(problems, verifyDoc(filePath, link, docID)...)
+	}
+
+	return problems
+}
+
+func verifyDoc(filePath string, link *config.Link, docID string) []string {
+	info, err := docStore.GetDocInfo(docID)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if info.Trashed {
+		return []string{fmt.Sprintf("%q is in the trash", info.Title)}
+	}
+
+	fp, err := docStore.GetFingerprint(docID)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if fp == nil {
+		return []string{fmt.Sprintf("%q has no push fingerprint; push once to record it", info.Title)}
+	}
+
+	var problems []string
+	pushed := fmt.Sprintf("docmd %s on %s at %s", fp.Version, fp.Machine, fp.PushedAt.Local().Format("2006-01-02 15:04:05"))
+
+	if !sameSource(fingerprintSource(filePath), fp.Source) {
+		problems = append(problems, fmt.Sprintf("%q was last pushed from %s (%s), not from this file", info.Title, fp.Source, pushed))
+	} else {
+		if fp.Machine != machineName() {
+			problems = append(problems, fmt.Sprintf("%q was last pushed from another machine (%s)", info.Title, pushed))
+		}
+		if fp.Hash != link.RenderedHash {
+			problems = append(problems, fmt.Sprintf("%q was last pushed by %s, with content this checkout didn't push", info.Title, pushed))
+		}
+	}
+
+	export, err := exportHash(docID)
+	if err != nil {
+		problems = append(problems, err.Error())
+	} else if export != fp.Export {
+		edited := fmt.Sprintf("%q was edited after the last push", info.Title)
+		if info.ModifiedBy != "" {
+			edited += " (last by " + info.ModifiedBy + ")"
+		}
+		problems = append(problems, edited)
+	}
+
+	return problems
+}
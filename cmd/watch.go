@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -10,10 +11,8 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/ohhmaar/docmd/internal/auth"
 	"github.com/ohhmaar/docmd/internal/config"
 	"github.com/ohhmaar/docmd/internal/convert"
-	"github.com/ohhmaar/docmd/internal/gdrive"
 	"github.com/ohhmaar/docmd/internal/sync"
 )
 
@@ -27,7 +26,12 @@ var watchCmd = &cobra.Command{
 	Short: "Watch for changes and auto-sync",
 	Long: `Watch a markdown file for changes and automatically push to Google Docs.
 
-Changes are debounced to avoid excessive API calls during rapid edits.`,
+Changes are debounced to avoid excessive API calls during rapid edits.
+Files embedded with snippet directives, the files of a book and the tab
+files of tabbed docs are watched as well.
+
+A change that would remove most of the doc, such as a file truncated by a
+bad merge, is skipped and logged; push it with 'docmd push' if intended.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runWatch,
 }
@@ -39,7 +43,7 @@ func init() {
 }
 
 func runWatch(cmd *cobra.Command, args []string) error {
-	if !auth.TokenExists() {
+	if !authenticated() {
 		printError("Not authenticated!")
 		fmt.Println("Run 'docmd init' first to authenticate with Google.")
 		return fmt.Errorf("not authenticated")
@@ -53,7 +57,13 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	var filesToWatch []string
 
 	if watchAll {
-		for filePath := range cfg.Links {
+		seen := make(map[string]bool)
+		for key := range cfg.Links {
+			filePath := config.SourcePath(key)
+			if seen[filePath] {
+				continue
+			}
+			seen[filePath] = true
 			if _, err := os.Stat(filePath); err == nil {
 				filesToWatch = append(filesToWatch, filePath)
 			}
@@ -65,7 +75,7 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		}
 	} else if len(args) == 1 {
 		absPath, _ := filepath.Abs(args[0])
-		if _, exists := cfg.GetLink(absPath); !exists {
+		if len(cfg.LinksForFile(absPath)) == 0 {
 			printError("File is not linked!")
 			fmt.Println("Use 'docmd link' to link this file first.")
 			return fmt.Errorf("file not linked")
@@ -92,8 +102,20 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Every variant of the file is pushed even when one of them fails.
 	syncFunc := func(filePath string) error {
-		return syncFile(cfg, filePath)
+		keys := cfg.LinksForFile(filePath)
+		failed := 0
+		for _, key := range keys {
+			if err := syncFile(cfg, key); err != nil {
+				fmt.Printf("[%s] Failed to sync %s: %v\n", time.Now().Format("15:04:05"), displayName(key, cfg.Links[key]), err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to sync %d of %d link(s) of %s", failed, len(keys), filepath.Base(filePath))
+		}
+		return nil
 	}
 
 	errChan := make(chan error, 1)
@@ -101,6 +123,17 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		watchConfig := sync.WatchConfig{
 			DebounceMs: watchDebounce,
 			OnChange:   syncFunc,
+			Dependencies: func(filePath string) []string {
+				deps, _ := convert.Dependencies(filePath)
+				for _, key := range cfg.LinksForFile(filePath) {
+					for _, member := range memberFiles(cfg.Links[key]) {
+						memberDeps, _ := convert.Dependencies(member)
+						deps = append(deps, member)
+						deps = append(deps, memberDeps...)
+					}
+				}
+				return deps
+			},
 		}
 
 		if len(filesToWatch) == 1 {
@@ -119,27 +152,47 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	}
 }
 
-func syncFile(cfg *config.Config, filePath string) error {
-	link, ok := cfg.GetLink(filePath)
+func syncFile(cfg *config.Config, key string) error {
+	link, ok := cfg.GetLink(key)
 	if !ok {
 		return fmt.Errorf("file not linked")
 	}
 
+	filePath := config.SourcePath(key)
 	timestamp := time.Now().Format("15:04:05")
-	fmt.Printf("[%s] Change detected in %s\n", timestamp, filepath.Base(filePath))
+	fmt.Printf("[%s] Change detected in %s\n", timestamp, displayName(key, link))
 	fmt.Printf("[%s] Pushing to Google Docs...\n", timestamp)
 
-	htmlContent, err := convert.FileToHTML(filePath)
+	remoteChanged, err := backupRemote(cfg, filePath, link)
 	if err != nil {
-		return fmt.Errorf("failed to convert markdown: %w", err)
+		return err
 	}
 
-	docInfo, err := gdrive.UpdateDoc(link.DocID, htmlContent)
+	docInfo, hash, err := uploadDoc(cfg, filePath, link, uploadOptions{overwrite: remoteChanged})
+	if errors.Is(err, errUnchanged) {
+		if err := cfg.UpdateSyncTime(key, link.LastRevisionID); err != nil {
+			fmt.Printf("[%s] Warning: failed to update sync time: %v\n", timestamp, err)
+		}
+		fmt.Printf("[%s] No changes in the rendered doc\n", timestamp)
+		return nil
+	}
+	if errors.Is(err, errShrinkRefused) {
+		fmt.Printf("[%s] Skipped: %v\n", timestamp, err)
+		fmt.Printf("[%s] Run 'docmd push' to push it anyway\n", timestamp)
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update Google Doc: %w", err)
+		return err
+	}
+
+	if err := finishUpload(cfg, filePath, link, hash); err != nil {
+		fmt.Printf("[%s] Warning: failed to finish document: %v\n", timestamp, err)
 	}
 
-	if err := cfg.UpdateSyncTime(filePath, docInfo.ModifiedTime.Format(time.RFC3339)); err != nil {
+	recordRevisions(filePath, link)
+	recordFingerprint(filePath, link)
+
+	if err := cfg.UpdateSyncTime(key, docInfo.ModifiedTime.Format(time.RFC3339)); err != nil {
 		fmt.Printf("[%s] Warning: failed to update sync time: %v\n", timestamp, err)
 	}
 
@@ -0,0 +1,213 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ohhmaar/docmd/internal/config"
+)
+
+const (
+	dirName      = "backups"
+	metaFileName = "backup.json"
+	htmlFileName = "doc.html"
+	mdFileName   = "doc.md"
+	idLayout     = "20060102-150405.000"
+)
+
+// Backup is a copy of a doc taken before a push overwrote it. Backups are
+// stored in ~/.docmd/backups/<doc-id>/<id>/.
+type Backup struct {
+	ID          string    `json:"id"`
+	DocID       string    `json:"doc_id"`
+	Title       string    `json:"title"`
+	Source      string    `json:"source,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	DocModified time.Time `json:"doc_modified"`
+	ModifiedBy  string    `json:"modified_by,omitempty"`
+	HasMarkdown bool      `json:"has_markdown"`
+
+	dir string
+}
+
+func docDir(docID string) (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, docID), nil
+}
+
+// Save stores a backup with the doc's HTML and, when the export succeeded,
+// its markdown. The ID is set from the creation time, with a suffix when
+// another backup of the doc has the same one.
+func Save(b *Backup, html, markdown []byte) error {
+	root, err := docDir(b.DocID)
+	if err != nil {
+		return err
+	}
+
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = time.Now()
+	}
+	b.HasMarkdown = markdown != nil
+
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	base := b.CreatedAt.Format(idLayout)
+	id := base
+	for n := 2; ; n++ {
+		b.ID = id
+		b.dir = filepath.Join(root, b.ID)
+		err := os.Mkdir(b.dir, 0700)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	meta, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{
+		metaFileName: meta,
+		htmlFileName: html,
+	}
+	if markdown != nil {
+		files[mdFileName] = markdown
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(b.dir, name), data, 0600); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// List returns the backups of a doc, newest first.
+func List(docID string) ([]*Backup, error) {
+	root, err := docDir(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	var backups []*Backup
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		b, err := load(filepath.Join(root, e.Name()))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, b)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// ListAll returns the backups of every doc, newest first.
+func ListAll() ([]*Backup, error) {
+	root, err := docDir("")
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	var backups []*Backup
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		docBackups, err := List(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, docBackups...)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+func load(dir string) (*Backup, error) {
+	data, err := os.ReadFile(filepath.Join(dir, metaFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var b Backup
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	b.dir = dir
+
+	return &b, nil
+}
+
+// Dir returns the directory holding the backup's files.
+func (b *Backup) Dir() string {
+	return b.dir
+}
+
+// HTML returns the doc as it was exported.
+func (b *Backup) HTML() ([]byte, error) {
+	return os.ReadFile(filepath.Join(b.dir, htmlFileName))
+}
+
+// Prune deletes the backups of a doc beyond the newest keep, and those older
+// than maxAge. Zero values don't limit. It returns the number deleted.
+func Prune(docID string, keep int, maxAge time.Duration) (int, error) {
+	backups, err := List(docID)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i, b := range backups {
+		tooMany := keep > 0 && i >= keep
+		tooOld := maxAge > 0 && time.Since(b.CreatedAt) > maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.RemoveAll(b.dir); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", b.ID, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
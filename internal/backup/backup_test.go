@@ -0,0 +1,83 @@
+package backup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaveIDs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	at := time.Date(2025, 3, 1, 14, 22, 10, 518_000_000, time.UTC)
+
+	var ids []string
+	for range 3 {
+		b := &Backup{DocID: "doc", CreatedAt: at}
+		if err := Save(b, []byte("<p>x</p>"), nil); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		ids = append(ids, b.ID)
+	}
+
+	want := []string{"20250301-142210.518", "20250301-142210.518-2", "20250301-142210.518-3"}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("IDs = %q, want %q", ids, want)
+			break
+		}
+	}
+
+	other := &Backup{DocID: "other", CreatedAt: at}
+	if err := Save(other, []byte("<p>y</p>"), []byte("y")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if other.ID != want[0] {
+		t.Errorf("another doc's backup at the same time got ID %q, want %q", other.ID, want[0])
+	}
+
+	backups, err := List("doc")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("List returned %d backups, want 3", len(backups))
+	}
+	html, err := backups[0].HTML()
+	if err != nil || string(html) != "<p>x</p>" {
+		t.Errorf("HTML() = %q, %v", html, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	now := time.Now()
+	for _, age := range []time.Duration{0, time.Hour, 48 * time.Hour, 72 * time.Hour} {
+		b := &Backup{DocID: "doc", CreatedAt: now.Add(-age)}
+		if err := Save(b, []byte("<p>x</p>"), nil); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	removed, err := Prune("doc", 3, 0)
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune by count removed %d, %v; want 1", removed, err)
+	}
+
+	removed, err = Prune("doc", 0, 24*time.Hour)
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune by age removed %d, %v; want 1", removed, err)
+	}
+
+	backups, err := List("doc")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(backups) != 2 || !backups[0].CreatedAt.After(backups[1].CreatedAt) {
+		t.Errorf("left %d backups, want the newest 2 in order", len(backups))
+	}
+
+	if removed, err := Prune("missing", 1, 0); err != nil || removed != 0 {
+		t.Errorf("Prune of a doc without backups removed %d, %v", removed, err)
+	}
+}
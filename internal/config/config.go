@@ -6,26 +6,162 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Version       int              `json:"version"`
-	DefaultFolder string           `json:"default_folder_id,omitempty"`
-	Links         map[string]*Link `json:"links"`
+	Version       int                         `json:"version"`
+	DefaultFolder string                      `json:"default_folder_id,omitempty"`
+	VaultRoot     string                      `json:"vault_root,omitempty"`
+	PersonChips   bool                        `json:"person_chips,omitempty"`
+	Banner        *BannerConfig               `json:"banner,omitempty"`
+	Page          *PageConfig                 `json:"page,omitempty"`
+	MaxShrink     float64                     `json:"max_shrink,omitempty"`
+	Secrets       *SecretsConfig              `json:"secrets,omitempty"`
+	Backups       *BackupConfig               `json:"backups,omitempty"`
+	Store         *StoreConfig                `json:"store,omitempty"`
+	Converters    map[string]*ConverterConfig `json:"converters,omitempty"`
+	Links         map[string]*Link            `json:"links"`
+}
+
+type BannerConfig struct {
+	Enabled bool   `json:"enabled"`
+	Header  string `json:"header,omitempty"`
+	Footer  string `json:"footer,omitempty"`
+}
+
+// ConverterConfig describes an external command that converts a source
+// format to HTML. It reads the source on stdin and writes HTML to stdout.
+type ConverterConfig struct {
+	Command        []string `json:"command"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+}
+
+// PageConfig holds page settings applied to docs after each upload. Size is
+// a name such as "a4" or "letter" and Margins a CSS-style shorthand of
+// lengths ("2cm", "1in 0.75in").
+type PageConfig struct {
+	Size        string `json:"size,omitempty"`
+	Orientation string `json:"orientation,omitempty"`
+	Margins     string `json:"margins,omitempty"`
+	Pageless    bool   `json:"pageless,omitempty"`
+}
+
+// SecretsConfig extends the secret scan run before every upload. Patterns
+// maps names to regular expressions; Allow lists matches, verbatim or by
+// their sha256 fingerprint, that may be uploaded anyway.
+type SecretsConfig struct {
+	Patterns map[string]string `json:"patterns,omitempty"`
+	Allow    []string          `json:"allow,omitempty"`
+}
+
+// BackupConfig controls the backups of docs taken before a push overwrites
+// changes made in them. Keep and MaxAgeDays limit how many are kept per
+// doc; zero means the default count and no age limit.
+type BackupConfig struct {
+	Disabled   bool `json:"disabled,omitempty"`
+	Keep       int  `json:"keep,omitempty"`
+	MaxAgeDays int  `json:"max_age_days,omitempty"`
+}
+
+// StoreConfig selects where docs are stored: "drive", the default, or
+// "local", which writes them under Dir (~/.docmd/store unless set) without
+// talking to Google.
+type StoreConfig struct {
+	Type string `json:"type,omitempty"`
+	Dir  string `json:"dir,omitempty"`
+}
+
+// PushedRevision is a doc revision created by a push, with the hash of the
+// local file it was rendered from.
+type PushedRevision struct {
+	DocID      string    `json:"doc_id"`
+	RevisionID string    `json:"revision_id"`
+	Hash       string    `json:"hash"`
+	PushedAt   time.Time `json:"pushed_at"`
+}
+
+// ContentSize is the size of a pushed document, as text characters and
+// blocks.
+type ContentSize struct {
+	Chars  int `json:"chars"`
+	Blocks int `json:"blocks"`
 }
 
 type Link struct {
-	DocID           string    `json:"doc_id"`
-	DocURL          string    `json:"doc_url"`
-	Title           string    `json:"title"`
-	CreatedAt       time.Time `json:"created_at"`
-	LastSync        time.Time `json:"last_sync"`
-	LastRevisionID  string    `json:"last_revision_id,omitempty"`
-	LocalHashAtSync string    `json:"local_hash_at_sync,omitempty"`
+	DocID           string            `json:"doc_id"`
+	DocURL          string            `json:"doc_url"`
+	Title           string            `json:"title"`
+	CreatedAt       time.Time         `json:"created_at"`
+	LastSync        time.Time         `json:"last_sync"`
+	LastRevisionID  string            `json:"last_revision_id,omitempty"`
+	LocalHashAtSync string            `json:"local_hash_at_sync,omitempty"`
+	PushedSize      *ContentSize      `json:"pushed_size,omitempty"`
+	RenderedHash    string            `json:"rendered_hash,omitempty"`
+	RenderedWith    string            `json:"rendered_with,omitempty"`
+	Revisions       []*PushedRevision `json:"revisions,omitempty"`
+	NoBanner        bool              `json:"no_banner,omitempty"`
+	Variant         string            `json:"variant,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	HideCode        bool              `json:"hide_code,omitempty"`
+	HideOutputs     bool              `json:"hide_outputs,omitempty"`
+	VaultRoot       string            `json:"vault_root,omitempty"`
+	PersonChips     bool              `json:"person_chips,omitempty"`
+	NumberHeadings  bool              `json:"number_headings,omitempty"`
+	NumberFrom      int               `json:"number_from,omitempty"`
+	Page            *PageConfig       `json:"page,omitempty"`
+
+	// Tabs links the file to a tabbed doc: one tab per H1 section, or one
+	// per file when TabFiles lists more files. TabIDs are the doc's tabs in
+	// order.
+	Tabs     bool     `json:"tabs,omitempty"`
+	TabFiles []string `json:"tab_files,omitempty"`
+	TabIDs   []string `json:"tab_ids,omitempty"`
+
+	// BookFiles are rendered after the file, in order, into the same doc.
+	BookFiles  []string `json:"book_files,omitempty"`
+	PageBreaks bool     `json:"page_breaks,omitempty"`
+
+	// Split links the file to a folder of docs, one per section starting
+	// at a heading of this level. DocID and DocURL are the folder's.
+	Split    int            `json:"split,omitempty"`
+	Sections []*SectionLink `json:"sections,omitempty"`
+}
+
+// SectionLink is the doc of one section of a split file.
+type SectionLink struct {
+	Title  string `json:"title"`
+	DocID  string `json:"doc_id"`
+	DocURL string `json:"doc_url"`
+}
+
+const (
+	currentVersion = 1
+	variantSep     = "::"
+)
+
+// LinkKey returns the key a link is stored under. A file can be linked to
+// several docs by giving each additional link a variant name.
+func LinkKey(filePath string, variant string) string {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	if variant == "" {
+		return absPath
+	}
+	return absPath + variantSep + variant
 }
 
-const currentVersion = 1
+// SourcePath returns the file a link key refers to.
+func SourcePath(key string) string {
+	if i := strings.LastIndex(key, variantSep); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
 
 func Load() (*Config, error) {
 	configPath, err := GetConfigPath()
@@ -75,12 +211,7 @@ func (c *Config) Save() error {
 }
 
 func (c *Config) AddLink(filePath string, link *Link) error {
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		return err
-	}
-
-	c.Links[absPath] = link
+	c.Links[LinkKey(filePath, link.Variant)] = link
 	return c.Save()
 }
 
@@ -94,6 +225,25 @@ func (c *Config) GetLink(filePath string) (*Link, bool) {
 	return link, ok
 }
 
+// LinksForFile returns the keys of every link whose source is filePath,
+// the default link first.
+func (c *Config) LinksForFile(filePath string) []string {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	for key := range c.Links {
+		if SourcePath(key) == absPath {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
 func (c *Config) RemoveLink(filePath string) error {
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
@@ -114,7 +264,7 @@ func (c *Config) UpdateSyncTime(filePath string, revisionID string) error {
 	link.LastRevisionID = revisionID
 
 	absPath, _ := filepath.Abs(filePath)
-	hash, err := HashFile(absPath)
+	hash, err := HashFile(SourcePath(absPath))
 	if err == nil {
 		link.LocalHashAtSync = hash
 	}
@@ -147,7 +297,7 @@ func (c *Config) HasLocalChanges(filePath string) (bool, error) {
 		return false, err
 	}
 
-	currentHash, err := HashFile(absPath)
+	currentHash, err := HashFile(SourcePath(absPath))
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,53 @@
+package convert
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const pageBreakHTML = "<p style=\"page-break-before: always\"></p>\n"
+
+var admonitionColors = map[string]string{
+	"NOTE":      "#e8f0fe",
+	"TIP":       "#e6f4ea",
+	"IMPORTANT": "#f3e8fd",
+	"WARNING":   "#fef7e0",
+	"CAUTION":   "#fce8e6",
+
+	// Obsidian callout types
+	"INFO":     "#e8f0fe",
+	"ABSTRACT": "#e4f7fb",
+	"SUMMARY":  "#e4f7fb",
+	"TODO":     "#e8f0fe",
+	"HINT":     "#e6f4ea",
+	"SUCCESS":  "#e6f4ea",
+	"DONE":     "#e6f4ea",
+	"QUESTION": "#fef7e0",
+	"FAQ":      "#fef7e0",
+	"FAILURE":  "#fce8e6",
+	"DANGER":   "#fce8e6",
+	"ERROR":    "#fce8e6",
+	"BUG":      "#fce8e6",
+	"EXAMPLE":  "#f3e8fd",
+	"QUOTE":    "#f1f3f4",
+}
+
+// admonitionHTML renders a callout box. Google Docs has no native
+// equivalent, so it becomes a shaded single-cell table.
+func admonitionHTML(kind string, title string, body string) string {
+	kind = strings.ToUpper(kind)
+	color, ok := admonitionColors[kind]
+	if !ok {
+		color = admonitionColors["NOTE"]
+	}
+
+	label := strings.ToUpper(kind[:1]) + strings.ToLower(kind[1:])
+	heading := fmt.Sprintf("<strong>%s</strong>", html.EscapeString(label))
+	if title != "" {
+		heading += ": " + html.EscapeString(title)
+	}
+
+	return fmt.Sprintf("<table class=\"docmd-admonition\"><tr><td style=\"background-color: %s; padding: 8px;\">\n<p>%s</p>\n%s</td></tr></table>\n",
+		color, heading, body)
+}
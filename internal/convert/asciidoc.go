@@ -0,0 +1,946 @@
+package convert
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type asciidocFormat struct{}
+
+func (asciidocFormat) Name() string { return "asciidoc" }
+
+func (asciidocFormat) Extensions() []string {
+	return []string{".adoc", ".asciidoc", ".asc"}
+}
+
+func (asciidocFormat) Body(source []byte, opts Options) (string, error) {
+	lines, err := resolveIncludes(source, opts.BaseDir, 0)
+	if err != nil {
+		return "", err
+	}
+
+	attrs := make(map[string]string)
+	for k, v := range opts.Tags {
+		attrs[k] = v
+	}
+
+	lines, err = filterAdocConditionals(lines, attrs)
+	if err != nil {
+		return "", err
+	}
+
+	p := &adocParser{lines: lines, attrs: attrs, ids: make(map[string]bool)}
+	return p.parseBlocks(func(string) bool { return false }), nil
+}
+
+func (asciidocFormat) Dependencies(source []byte, baseDir string) []string {
+	var deps []string
+	for _, line := range strings.Split(string(source), "\n") {
+		if m := adocInclude.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+			deps = append(deps, resolvePath(m[1], baseDir))
+		}
+	}
+	return deps
+}
+
+var (
+	adocInclude    = regexp.MustCompile(`^include::([^\[]+)\[(.*)\]$`)
+	adocCondition  = regexp.MustCompile(`^(ifdef|ifndef|endif)::([^\[]*)\[(.*)\]$`)
+	adocAttrDef    = regexp.MustCompile(`^:(!?[\w-]+!?):\s*(.*)$`)
+	adocHeading    = regexp.MustCompile(`^(={1,6})\s+(.+)$`)
+	adocAnchor     = regexp.MustCompile(`^\[\[([\w:.-]+)(?:,\s*(.*))?\]\]$`)
+	adocBlockAttrs = regexp.MustCompile(`^\[([^\[\]]*)\]$`)
+	adocBlockTitle = regexp.MustCompile(`^\.([^.\s].*)$`)
+	adocListItem   = regexp.MustCompile(`^\s*(\*{1,5}|-|\.{1,5}|\d+\.)\s+(.*)$`)
+	adocDescItem   = regexp.MustCompile(`^(.+?)(::|;;)(?:\s+(.*))?$`)
+	adocAdmonition = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+	adocBlockImage = regexp.MustCompile(`^image::([^\[]+)\[(.*)\]$`)
+)
+
+func resolvePath(path string, baseDir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// resolveIncludes expands include:: directives, honouring the lines= and
+// tag= attributes.
+func resolveIncludes(source []byte, baseDir string, depth int) ([]string, error) {
+	if depth > 8 {
+		return nil, fmt.Errorf("includes nested too deeply")
+	}
+
+	var out []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n") {
+		m := adocInclude.FindStringSubmatch(line)
+		if m == nil {
+			out = append(out, line)
+			continue
+		}
+
+		path := resolvePath(m[1], baseDir)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", m[1], err)
+		}
+
+		included, err := resolveIncludes(data, filepath.Dir(path), depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		_, opts := parseAdocAttrs(m[2])
+		if spec := opts["lines"]; spec != "" {
+			included, err = includeLines(included, spec)
+		} else if tag := opts["tag"]; tag != "" {
+			included, err = includeTag(included, tag)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", m[1], err)
+		}
+
+		out = append(out, included...)
+	}
+
+	return out, nil
+}
+
+func includeLines(lines []string, spec string) ([]string, error) {
+	var out []string
+	for _, part := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ';' }) {
+		startStr, endStr, isRange := strings.Cut(strings.TrimSpace(part), "..")
+		start, err := strconv.Atoi(startStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lines %q", spec)
+		}
+		end := start
+		if isRange {
+			if endStr == "-1" || endStr == "" {
+				end = len(lines)
+			} else if end, err = strconv.Atoi(endStr); err != nil {
+				return nil, fmt.Errorf("invalid lines %q", spec)
+			}
+		}
+		if start < 1 || end > len(lines) || end < start {
+			return nil, fmt.Errorf("lines %s out of range", part)
+		}
+		out = append(out, lines[start-1:end]...)
+	}
+	return out, nil
+}
+
+func includeTag(lines []string, tag string) ([]string, error) {
+	var out []string
+	inside := false
+	for _, line := range lines {
+		if strings.Contains(line, "tag::"+tag+"[]") {
+			inside = true
+			continue
+		}
+		if strings.Contains(line, "end::"+tag+"[]") {
+			return out, nil
+		}
+		if inside {
+			out = append(out, line)
+		}
+	}
+	if !inside {
+		return nil, fmt.Errorf("tag %q not found", tag)
+	}
+	return nil, fmt.Errorf("tag %q is never closed", tag)
+}
+
+// filterAdocConditionals evaluates ifdef/ifndef/endif preprocessor
+// directives. Attributes defined in the document header count as defined,
+// as do the link's tags.
+func filterAdocConditionals(lines []string, attrs map[string]string) ([]string, error) {
+	var out []string
+	var stack []bool
+	active := true
+
+	for i, line := range lines {
+		if m := adocAttrDef.FindStringSubmatch(line); m != nil && active {
+			name := m[1]
+			if strings.HasPrefix(name, "!") || strings.HasSuffix(name, "!") {
+				delete(attrs, strings.Trim(name, "!"))
+			} else {
+				attrs[name] = m[2]
+			}
+		}
+
+		m := adocCondition.FindStringSubmatch(line)
+		if m == nil {
+			if active {
+				out = append(out, line)
+			}
+			continue
+		}
+
+		directive, names, content := m[1], m[2], m[3]
+		if directive == "endif" {
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: endif without ifdef", i+1)
+			}
+			active = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		matched := adocDefined(names, attrs)
+		if directive == "ifndef" {
+			matched = !matched
+		}
+
+		if content != "" {
+			if active && matched {
+				out = append(out, content)
+			}
+			continue
+		}
+
+		stack = append(stack, active)
+		active = active && matched
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("ifdef is never closed")
+	}
+
+	return out, nil
+}
+
+func adocDefined(names string, attrs map[string]string) bool {
+	if strings.Contains(names, "+") {
+		for _, n := range strings.Split(names, "+") {
+			if _, ok := attrs[n]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	for _, n := range strings.Split(names, ",") {
+		if _, ok := attrs[n]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAdocAttrs splits a block or macro attribute list into positional
+// and named attributes.
+func parseAdocAttrs(s string) ([]string, map[string]string) {
+	var positional []string
+	named := make(map[string]string)
+
+	var fields []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	fields = append(fields, current.String())
+
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if k, v, ok := strings.Cut(f, "="); ok && !strings.ContainsAny(k, " ") {
+			named[k] = strings.Trim(v, `"`)
+		} else {
+			positional = append(positional, f)
+		}
+	}
+
+	return positional, named
+}
+
+type adocParser struct {
+	lines []string
+	pos   int
+	attrs map[string]string
+	ids   map[string]bool
+
+	pendingID    string
+	pendingTitle string
+	pendingAttrs []string
+	pendingNamed map[string]string
+}
+
+func (p *adocParser) peek() (string, bool) {
+	if p.pos >= len(p.lines) {
+		return "", false
+	}
+	return p.lines[p.pos], true
+}
+
+func (p *adocParser) takePending() (id, title string, attrs []string, named map[string]string) {
+	id, title, attrs, named = p.pendingID, p.pendingTitle, p.pendingAttrs, p.pendingNamed
+	p.pendingID, p.pendingTitle, p.pendingAttrs, p.pendingNamed = "", "", nil, nil
+	return
+}
+
+func isAdocDelimiter(line string) bool {
+	switch line {
+	case "----", "....", "====", "****", "____", "|===", "--", "++++", "////":
+		return true
+	}
+	return false
+}
+
+// parseBlocks parses blocks until the input ends or stop returns true for
+// the current line, which is left unconsumed.
+func (p *adocParser) parseBlocks(stop func(string) bool) string {
+	var out strings.Builder
+
+	for {
+		line, ok := p.peek()
+		if !ok || stop(line) {
+			break
+		}
+		trimmed := strings.TrimRight(line, " \t")
+		start := p.pos
+
+		switch {
+		case trimmed == "":
+			p.pos++
+
+		case trimmed == "////":
+			p.pos++
+			p.collectUntil("////")
+
+		case strings.HasPrefix(trimmed, "//") && !strings.HasPrefix(trimmed, "///"):
+			p.pos++
+
+		case adocAttrDef.MatchString(trimmed):
+			m := adocAttrDef.FindStringSubmatch(trimmed)
+			if !strings.ContainsAny(m[1], "!") {
+				p.attrs[m[1]] = m[2]
+			}
+			p.pos++
+
+		case adocAnchor.MatchString(trimmed):
+			p.pendingID = adocAnchor.FindStringSubmatch(trimmed)[1]
+			p.pos++
+
+		case adocBlockAttrs.MatchString(trimmed) && !strings.HasPrefix(trimmed, "[["):
+			positional, named := parseAdocAttrs(adocBlockAttrs.FindStringSubmatch(trimmed)[1])
+			if len(positional) > 0 && strings.HasPrefix(positional[0], "#") {
+				p.pendingID = strings.TrimPrefix(positional[0], "#")
+				positional = positional[1:]
+			}
+			p.pendingAttrs = positional
+			p.pendingNamed = named
+			p.pos++
+
+		case adocBlockTitle.MatchString(trimmed) && !adocListItem.MatchString(trimmed):
+			p.pendingTitle = adocBlockTitle.FindStringSubmatch(trimmed)[1]
+			p.pos++
+
+		case adocHeading.MatchString(trimmed):
+			out.WriteString(p.heading(trimmed))
+			p.pos++
+
+		case trimmed == "'''" || trimmed == "---" || trimmed == "***":
+			p.takePending()
+			out.WriteString("<hr/>\n")
+			p.pos++
+
+		case trimmed == "<<<":
+			p.takePending()
+			out.WriteString(pageBreakHTML)
+			p.pos++
+
+		case isAdocDelimiter(trimmed):
+			out.WriteString(p.delimitedBlock(trimmed))
+
+		case adocBlockImage.MatchString(trimmed):
+			m := adocBlockImage.FindStringSubmatch(trimmed)
+			_, title, _, _ := p.takePending()
+			out.WriteString(p.image(m[1], m[2]))
+			if title != "" {
+				fmt.Fprintf(&out, "<p><em>%s</em></p>\n", p.inline(title))
+			}
+			p.pos++
+
+		case adocListItem.MatchString(line):
+			out.WriteString(p.list())
+
+		case adocAdmonition.MatchString(trimmed):
+			m := adocAdmonition.FindStringSubmatch(trimmed)
+			p.pos++
+			text := p.paragraphLines(m[2])
+			_, title, _, _ := p.takePending()
+			out.WriteString(admonitionHTML(m[1], title, "<p>"+p.inline(text)+"</p>\n"))
+
+		case descItem(line) != nil:
+			out.WriteString(p.descriptionList())
+
+		default:
+			out.WriteString(p.paragraph())
+		}
+
+		// A block that consumed nothing would select the same line again.
+		if p.pos == start {
+			out.WriteString(p.paragraph())
+		}
+	}
+
+	return out.String()
+}
+
+func (p *adocParser) heading(line string) string {
+	m := adocHeading.FindStringSubmatch(line)
+	level := len(m[1])
+	id, _, _, _ := p.takePending()
+	if id == "" {
+		id = p.autoID(m[2])
+	}
+	return fmt.Sprintf("<h%d id=\"%s\">%s</h%d>\n", level, html.EscapeString(id), p.inline(m[2]), level)
+}
+
+var nonWord = regexp.MustCompile(`[^\w]+`)
+
+func (p *adocParser) autoID(title string) string {
+	base := "_" + strings.Trim(nonWord.ReplaceAllString(strings.ToLower(title), "_"), "_")
+	id := base
+	for n := 2; p.ids[id]; n++ {
+		id = fmt.Sprintf("%s_%d", base, n)
+	}
+	p.ids[id] = true
+	return id
+}
+
+func (p *adocParser) collectUntil(delimiter string) []string {
+	var content []string
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		p.pos++
+		if strings.TrimRight(line, " \t") == delimiter {
+			break
+		}
+		content = append(content, line)
+	}
+	return content
+}
+
+func (p *adocParser) delimitedBlock(delimiter string) string {
+	id, title, attrs, named := p.takePending()
+	p.pos++
+
+	style := ""
+	if len(attrs) > 0 {
+		style = attrs[0]
+	}
+
+	var out strings.Builder
+	if title != "" && delimiter != "====" {
+		fmt.Fprintf(&out, "<p><strong>%s</strong></p>\n", p.inline(title))
+	}
+
+	switch delimiter {
+	case "----", "....":
+		content := p.collectUntil(delimiter)
+		lang := ""
+		if style == "source" && len(attrs) > 1 {
+			lang = attrs[1]
+		} else if named["language"] != "" {
+			lang = named["language"]
+		}
+		class := ""
+		if lang != "" {
+			class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(lang))
+		}
+		fmt.Fprintf(&out, "<pre><code%s>%s\n</code></pre>\n", class, html.EscapeString(strings.Join(content, "\n")))
+
+	case "++++":
+		content := p.collectUntil(delimiter)
+		out.WriteString(strings.Join(content, "\n") + "\n")
+
+	case "|===":
+		out.WriteString(p.table(p.collectUntil(delimiter), attrs, named))
+
+	case "====":
+		inner := p.nested(delimiter)
+		if kind := strings.ToUpper(style); adocAdmonition.MatchString(kind + ": x") {
+			out.WriteString(admonitionHTML(kind, title, inner))
+		} else {
+			if title != "" {
+				fmt.Fprintf(&out, "<p><strong>%s</strong></p>\n", p.inline(title))
+			}
+			out.WriteString("<blockquote>\n" + inner + "</blockquote>\n")
+		}
+
+	case "____":
+		inner := p.nested(delimiter)
+		out.WriteString("<blockquote>\n" + inner)
+		if style == "quote" && len(attrs) > 1 {
+			cite := strings.Join(attrs[1:], ", ")
+			fmt.Fprintf(&out, "<p>&#8212; %s</p>\n", p.inline(cite))
+		}
+		out.WriteString("</blockquote>\n")
+
+	case "****":
+		out.WriteString("<blockquote>\n" + p.nested(delimiter) + "</blockquote>\n")
+
+	default:
+		out.WriteString(p.nested(delimiter))
+	}
+
+	if id != "" {
+		return fmt.Sprintf("<a id=\"%s\"></a>\n", html.EscapeString(id)) + out.String()
+	}
+	return out.String()
+}
+
+func (p *adocParser) nested(delimiter string) string {
+	inner := p.parseBlocks(func(line string) bool {
+		return strings.TrimRight(line, " \t") == delimiter
+	})
+	p.pos++
+	return inner
+}
+
+var adocCellSpec = regexp.MustCompile(`^(\d+\*|\d*(\.\d+)?\+)?[<^>]?(\.[<^>])?[adehlmsv]?$`)
+
+func adocColumnCount(spec string) int {
+	if spec == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(spec); err == nil {
+		return n
+	}
+
+	cols := 0
+	for _, c := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ';' }) {
+		if n, _, ok := strings.Cut(c, "*"); ok {
+			if count, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+				cols += count
+				continue
+			}
+		}
+		cols++
+	}
+	return cols
+}
+
+func (p *adocParser) table(lines []string, attrs []string, named map[string]string) string {
+	options := named["options"] + " " + named["opts"] + " " + strings.Join(attrs, " ")
+	header := strings.Contains(options, "header") && !strings.Contains(options, "noheader")
+	implicitHeader := !strings.Contains(options, "noheader")
+	cols := adocColumnCount(named["cols"])
+
+	var cells []string
+	first := true
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		parts := strings.Split(trimmed, "|")
+		if first && len(parts) > 1 {
+			first = false
+			if cols == 0 {
+				cols = len(parts) - 1
+			}
+			if implicitHeader && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
+				header = true
+			}
+		}
+
+		if parts[0] != "" && !adocCellSpec.MatchString(parts[0]) && len(cells) > 0 {
+			cells[len(cells)-1] += " " + parts[0]
+		}
+		for _, cell := range parts[1:] {
+			cells = append(cells, strings.TrimSpace(cell))
+		}
+	}
+
+	if cols == 0 || len(cells) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("<table>\n")
+	bodyOpen := false
+	for r := 0; r*cols < len(cells); r++ {
+		row := cells[r*cols:]
+		if len(row) > cols {
+			row = row[:cols]
+		}
+
+		tag := "td"
+		if r == 0 && header {
+			tag = "th"
+			out.WriteString("<thead>\n")
+		} else if !bodyOpen {
+			out.WriteString("<tbody>\n")
+			bodyOpen = true
+		}
+
+		out.WriteString("<tr>\n")
+		for _, cell := range row {
+			fmt.Fprintf(&out, "<%s>%s</%s>\n", tag, p.inline(cell), tag)
+		}
+		out.WriteString("</tr>\n")
+
+		if r == 0 && header {
+			out.WriteString("</thead>\n")
+		}
+	}
+	if bodyOpen {
+		out.WriteString("</tbody>\n")
+	}
+	out.WriteString("</table>\n")
+
+	return out.String()
+}
+
+type adocListFrame struct {
+	marker  string
+	ordered bool
+}
+
+func (p *adocParser) list() string {
+	p.takePending()
+
+	var out strings.Builder
+	var stack []adocListFrame
+
+	// Every frame on the stack has an open list and an open item.
+	closeTo := func(depth int) {
+		for len(stack) > depth {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if top.ordered {
+				out.WriteString("</li>\n</ol>\n")
+			} else {
+				out.WriteString("</li>\n</ul>\n")
+			}
+		}
+	}
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		m := adocListItem.FindStringSubmatch(line)
+
+		if m == nil {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "+" {
+				p.pos++
+				if next, ok := p.peek(); ok && isAdocDelimiter(strings.TrimSpace(next)) {
+					out.WriteString(p.delimitedBlock(strings.TrimSpace(next)))
+				} else {
+					out.WriteString("<p>" + p.inline(p.paragraphLines("")) + "</p>\n")
+				}
+				continue
+			}
+			// A blank line followed by an item of a list that is already
+			// open continues it.
+			if trimmed == "" && p.pos+1 < len(p.lines) {
+				if next := adocListItem.FindStringSubmatch(p.lines[p.pos+1]); next != nil && stackHas(stack, listMarker(next[1])) {
+					p.pos++
+					continue
+				}
+			}
+			break
+		}
+
+		marker := listMarker(m[1])
+		ordered := strings.HasPrefix(marker, ".") || marker == "1."
+
+		depth := -1
+		for i, f := range stack {
+			if f.marker == marker {
+				depth = i
+				break
+			}
+		}
+
+		if depth >= 0 {
+			closeTo(depth + 1)
+			out.WriteString("</li>\n")
+		} else {
+			stack = append(stack, adocListFrame{marker: marker, ordered: ordered})
+			if ordered {
+				out.WriteString("<ol>\n")
+			} else {
+				out.WriteString("<ul>\n")
+			}
+		}
+
+		p.pos++
+		text := p.paragraphLines(m[2])
+
+		checkbox := ""
+		switch {
+		case strings.HasPrefix(text, "[ ] "):
+			checkbox, text = "&#9744; ", text[4:]
+		case strings.HasPrefix(text, "[x] "), strings.HasPrefix(text, "[*] "):
+			checkbox, text = "&#9745; ", text[4:]
+		}
+		out.WriteString("<li>" + checkbox + p.inline(text) + "\n")
+	}
+
+	closeTo(0)
+	return out.String()
+}
+
+func listMarker(marker string) string {
+	if marker[0] >= '0' && marker[0] <= '9' {
+		return "1."
+	}
+	return marker
+}
+
+func stackHas(stack []adocListFrame, marker string) bool {
+	for _, f := range stack {
+		if f.marker == marker {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *adocParser) descriptionList() string {
+	p.takePending()
+
+	var out strings.Builder
+	out.WriteString("<dl>\n")
+	for p.pos < len(p.lines) {
+		trimmed := strings.TrimSpace(p.lines[p.pos])
+		m := descItem(p.lines[p.pos])
+		if trimmed == "" {
+			if p.pos+1 < len(p.lines) && descItem(p.lines[p.pos+1]) != nil {
+				p.pos++
+				continue
+			}
+			break
+		}
+		if m == nil {
+			break
+		}
+		p.pos++
+		fmt.Fprintf(&out, "<dt><strong>%s</strong></dt>\n", p.inline(m[1]))
+		desc := m[3]
+		if desc == "" {
+			if next, ok := p.peek(); ok && strings.TrimSpace(next) != "" && descItem(next) == nil {
+				desc = strings.TrimSpace(next)
+				p.pos++
+			}
+		}
+		desc = p.paragraphLines(desc)
+		fmt.Fprintf(&out, "<dd>%s</dd>\n", p.inline(desc))
+	}
+	out.WriteString("</dl>\n")
+	return out.String()
+}
+
+// descItem matches a description list item, "term:: description", and
+// returns nil for other lines, including URLs.
+func descItem(line string) []string {
+	trimmed := strings.TrimSpace(line)
+	if strings.Contains(trimmed, "://") {
+		return nil
+	}
+	return adocDescItem.FindStringSubmatch(trimmed)
+}
+
+// paragraphLines joins first with the following lines of the same paragraph.
+func (p *adocParser) paragraphLines(first string) string {
+	parts := []string{}
+	if first != "" {
+		parts = append(parts, first)
+	}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "+" || isAdocDelimiter(trimmed) || adocListItem.MatchString(line) ||
+			adocHeading.MatchString(trimmed) || adocBlockAttrs.MatchString(trimmed) || adocAnchor.MatchString(trimmed) ||
+			(strings.HasPrefix(trimmed, "//") && !strings.HasPrefix(trimmed, "///")) {
+			break
+		}
+		parts = append(parts, trimmed)
+		p.pos++
+	}
+	return strings.Join(parts, "\n")
+}
+
+func (p *adocParser) paragraph() string {
+	id, title, attrs, _ := p.takePending()
+
+	first := strings.TrimSpace(p.lines[p.pos])
+	literal := strings.HasPrefix(p.lines[p.pos], " ")
+	p.pos++
+
+	var out strings.Builder
+	if id != "" {
+		fmt.Fprintf(&out, "<a id=\"%s\"></a>\n", html.EscapeString(id))
+	}
+	if title != "" {
+		fmt.Fprintf(&out, "<p><strong>%s</strong></p>\n", p.inline(title))
+	}
+
+	if literal {
+		lines := []string{first}
+		for p.pos < len(p.lines) && strings.TrimSpace(p.lines[p.pos]) != "" {
+			lines = append(lines, strings.TrimSpace(p.lines[p.pos]))
+			p.pos++
+		}
+		fmt.Fprintf(&out, "<pre>%s</pre>\n", html.EscapeString(strings.Join(lines, "\n")))
+		return out.String()
+	}
+
+	text := p.paragraphLines(first)
+
+	if len(attrs) > 0 {
+		if kind := strings.ToUpper(attrs[0]); adocAdmonition.MatchString(kind + ": x") {
+			return out.String() + admonitionHTML(kind, "", "<p>"+p.inline(text)+"</p>\n")
+		}
+		if attrs[0] == "source" || attrs[0] == "listing" || attrs[0] == "literal" {
+			return out.String() + fmt.Sprintf("<pre><code>%s\n</code></pre>\n", html.EscapeString(text))
+		}
+	}
+
+	out.WriteString("<p>" + p.inline(text) + "</p>\n")
+	return out.String()
+}
+
+func (p *adocParser) image(target string, attrList string) string {
+	positional, named := parseAdocAttrs(attrList)
+	alt := named["alt"]
+	if alt == "" && len(positional) > 0 {
+		alt = positional[0]
+	}
+	if alt == "" {
+		alt = strings.TrimSuffix(filepath.Base(target), filepath.Ext(target))
+	}
+
+	if dir := p.attrs["imagesdir"]; dir != "" && !strings.Contains(target, "://") && !filepath.IsAbs(target) {
+		target = strings.TrimSuffix(dir, "/") + "/" + target
+	}
+
+	size := ""
+	if w := named["width"]; w != "" {
+		size += fmt.Sprintf(` width="%s"`, html.EscapeString(w))
+	} else if len(positional) > 1 {
+		size += fmt.Sprintf(` width="%s"`, html.EscapeString(positional[1]))
+	}
+
+	return fmt.Sprintf("<p><img src=\"%s\" alt=\"%s\"%s/></p>\n", html.EscapeString(target), html.EscapeString(alt), size)
+}
+
+var (
+	adocAttrRef      = regexp.MustCompile(`\{([\w-]+)\}`)
+	adocMonospace    = regexp.MustCompile("`([^`]+)`")
+	adocStrongU      = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	adocStrong       = regexp.MustCompile(`(^|[^\w*])\*([^*\s](?:[^*]*[^*\s])?)\*($|[^\w*])`)
+	adocEmphasisU    = regexp.MustCompile(`__(.+?)__`)
+	adocEmphasis     = regexp.MustCompile(`(^|[^\w_])_([^_\s](?:[^_]*[^_\s])?)_($|[^\w_])`)
+	adocMark         = regexp.MustCompile(`(^|[^\w#])#([^#\s](?:[^#]*[^#\s])?)#($|[^\w#])`)
+	adocLinkMacro    = regexp.MustCompile(`(?:link:)?((?:https?|ftp|mailto):[^\s\[]+)\[([^\]]*)\]`)
+	adocPlainLink    = regexp.MustCompile(`(^|[\s(])((?:https?|ftp)://[^\s<\[\]]+[^\s<\[\].,;:!?)])`)
+	adocLocalLink    = regexp.MustCompile(`link:([^\s\[]+)\[([^\]]*)\]`)
+	adocXref         = regexp.MustCompile(`&lt;&lt;([\w:.#-]+)(?:,\s*([^&]*?))?&gt;&gt;`)
+	adocXrefMacro    = regexp.MustCompile(`xref:([\w:.#/-]+)\[([^\]]*)\]`)
+	adocInlineImage  = regexp.MustCompile(`image:([^\s:\[][^\s\[]*)\[([^\]]*)\]`)
+	adocLineBreak    = regexp.MustCompile(` \+\n`)
+	adocPlaceholder  = regexp.MustCompile("\x00(\\d+)\x00")
+	adocEscapedMacro = regexp.MustCompile(`\\([*_#` + "`" + `])`)
+)
+
+// inline applies AsciiDoc inline formatting to text and returns HTML.
+func (p *adocParser) inline(text string) string {
+	text = adocAttrRef.ReplaceAllStringFunc(text, func(ref string) string {
+		if v, ok := p.attrs[ref[1:len(ref)-1]]; ok {
+			return v
+		}
+		return ref
+	})
+
+	var spans []string
+	hold := func(s string) string {
+		spans = append(spans, s)
+		return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+	}
+
+	text = adocMonospace.ReplaceAllStringFunc(text, func(s string) string {
+		return hold("<code>" + html.EscapeString(s[1:len(s)-1]) + "</code>")
+	})
+	text = adocInlineImage.ReplaceAllStringFunc(text, func(s string) string {
+		m := adocInlineImage.FindStringSubmatch(s)
+		return hold(strings.TrimSuffix(strings.TrimPrefix(p.image(m[1], m[2]), "<p>"), "</p>\n"))
+	})
+	text = adocLinkMacro.ReplaceAllStringFunc(text, func(s string) string {
+		m := adocLinkMacro.FindStringSubmatch(s)
+		target := strings.TrimPrefix(m[1], "link:")
+		label := m[2]
+		if label == "" {
+			label = strings.TrimPrefix(target, "mailto:")
+		}
+		return hold(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(target), html.EscapeString(label)))
+	})
+	text = adocLocalLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := adocLocalLink.FindStringSubmatch(s)
+		return hold(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(m[1]), html.EscapeString(m[2])))
+	})
+	text = adocXrefMacro.ReplaceAllStringFunc(text, func(s string) string {
+		m := adocXrefMacro.FindStringSubmatch(s)
+		label := m[2]
+		if label == "" {
+			label = m[1]
+		}
+		target := m[1]
+		if !strings.Contains(target, "#") && !strings.Contains(target, ".") {
+			target = "#" + target
+		}
+		return hold(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(target), html.EscapeString(label)))
+	})
+	text = adocPlainLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := adocPlainLink.FindStringSubmatch(s)
+		return m[1] + hold(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(m[2]), html.EscapeString(m[2])))
+	})
+	text = adocEscapedMacro.ReplaceAllStringFunc(text, func(s string) string {
+		return hold(html.EscapeString(s[1:]))
+	})
+
+	text = html.EscapeString(text)
+
+	text = adocXref.ReplaceAllStringFunc(text, func(s string) string {
+		m := adocXref.FindStringSubmatch(s)
+		label := m[2]
+		if label == "" {
+			label = m[1]
+		}
+		return fmt.Sprintf(`<a href="#%s">%s</a>`, m[1], label)
+	})
+
+	text = adocStrongU.ReplaceAllString(text, "<strong>$1</strong>")
+	text = adocEmphasisU.ReplaceAllString(text, "<em>$1</em>")
+	for i := 0; i < 2; i++ {
+		text = adocStrong.ReplaceAllString(text, "$1<strong>$2</strong>$3")
+		text = adocEmphasis.ReplaceAllString(text, "$1<em>$2</em>$3")
+		text = adocMark.ReplaceAllString(text, "$1<mark>$2</mark>$3")
+	}
+	text = adocLineBreak.ReplaceAllString(text, "<br/>\n")
+
+	return adocPlaceholder.ReplaceAllStringFunc(text, func(s string) string {
+		n, _ := strconv.Atoi(adocPlaceholder.FindStringSubmatch(s)[1])
+		return spans[n]
+	})
+}
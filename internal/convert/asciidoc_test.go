@@ -0,0 +1,41 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsciidocIndentedDescriptionMarkers(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"indented marker without term", "Intro.\n\n   :: x\n", ":: x"},
+		{"lisp comment in literal paragraph", "  ;; comment\n  (car list)\n", ";; comment"},
+		{"indented description item", "  term:: text\n", "<dt><strong>term</strong></dt>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan string, 1)
+			go func() {
+				out, err := asciidocFormat{}.Body([]byte(tt.source), Options{})
+				if err != nil {
+					t.Errorf("Body: %v", err)
+				}
+				done <- out
+			}()
+
+			select {
+			case out := <-done:
+				if !strings.Contains(out, tt.want) {
+					t.Errorf("output %q doesn't contain %q", out, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("rendering did not finish")
+			}
+		})
+	}
+}
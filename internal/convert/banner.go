@@ -0,0 +1,95 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBannerHeader = `This document is generated from <b>{{.Path}}</b>{{if .Repo}} in {{.Repo}}{{end}}{{if .Commit}} ({{.Commit}}){{end}}. Edits made here will be overwritten by the next push.`
+	DefaultBannerFooter = `Generated by docmd on {{.PushedAt.Format "2006-01-02 15:04 MST"}}.`
+)
+
+type BannerData struct {
+	Repo     string
+	Path     string
+	Commit   string
+	Branch   string
+	PushedAt time.Time
+}
+
+type Banner struct {
+	Header string
+	Footer string
+	Data   BannerData
+}
+
+func (b *Banner) wrap(body string) (string, error) {
+	header, err := renderBannerTemplate("header", b.Header, b.Data)
+	if err != nil {
+		return "", err
+	}
+
+	footer, err := renderBannerTemplate("footer", b.Footer, b.Data)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if header != "" {
+		sb.WriteString(bannerParagraph(header) + "\n<hr/>\n")
+	}
+	sb.WriteString(body)
+	if footer != "" {
+		sb.WriteString("<hr/>\n" + bannerParagraph(footer) + "\n")
+	}
+
+	return sb.String(), nil
+}
+
+// Stamp renders the banner of a document rendered with b again with the
+// given data. Documents are rendered without the fields that change with
+// every push or commit, such as PushedAt and Commit, so that they only
+// differ when their content does; those are filled in by Stamp.
+func (b *Banner) Stamp(doc string, stamped BannerData) (string, error) {
+	for _, part := range []struct{ name, text string }{{"header", b.Header}, {"footer", b.Footer}} {
+		rendered, err := renderBannerTemplate(part.name, part.text, b.Data)
+		if err != nil {
+			return "", err
+		}
+		final, err := renderBannerTemplate(part.name, part.text, stamped)
+		if err != nil {
+			return "", err
+		}
+		if rendered != "" && rendered != final {
+			doc = strings.Replace(doc, bannerParagraph(rendered), bannerParagraph(final), 1)
+		}
+	}
+
+	return doc, nil
+}
+
+func bannerParagraph(text string) string {
+	return `<p class="docmd-banner">` + text + "</p>"
+}
+
+func renderBannerTemplate(name, text string, data BannerData) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid banner %s template: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render banner %s: %w", name, err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
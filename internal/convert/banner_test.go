@@ -0,0 +1,42 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBannerStamp(t *testing.T) {
+	banner := &Banner{
+		Header: DefaultBannerHeader,
+		Footer: DefaultBannerFooter,
+		Data:   BannerData{Repo: "github.com/a/b", Path: "docs/x.md"},
+	}
+
+	doc, err := banner.wrap("<p>Body</p>\n")
+	if err != nil {
+		t.Fatalf("wrap: %v", err)
+	}
+	if strings.Contains(doc, "(") {
+		t.Errorf("unstamped banner %q shouldn't have a commit", doc)
+	}
+
+	data := banner.Data
+	data.Commit = "abc1234"
+	data.PushedAt = time.Date(2025, 3, 1, 14, 22, 0, 0, time.UTC)
+
+	stamped, err := banner.Stamp(doc, data)
+	if err != nil {
+		t.Fatalf("Stamp: %v", err)
+	}
+
+	want := *banner
+	want.Data = data
+	wantDoc, err := want.wrap("<p>Body</p>\n")
+	if err != nil {
+		t.Fatalf("wrap: %v", err)
+	}
+	if stamped != wantDoc {
+		t.Errorf("Stamp gave\n%s\nwant\n%s", stamped, wantDoc)
+	}
+}
@@ -0,0 +1,143 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// bookFormat renders a manifest listing the files of a book, one path or
+// glob per line relative to the manifest. Blank lines and lines starting
+// with # are ignored, and front matter can set page_breaks.
+//
+//	---
+//	page_breaks: true
+//	---
+//	01-intro.md
+//	chapters/*.md
+type bookFormat struct{}
+
+func (bookFormat) Name() string { return "book" }
+
+func (bookFormat) Extensions() []string { return []string{".book"} }
+
+func (bookFormat) Body(source []byte, opts Options) (string, error) {
+	fm, files, err := parseBook(source, opts.BaseDir)
+	if err != nil {
+		return "", err
+	}
+	if breaks, ok := fm.Bool("page_breaks"); ok && !opts.PageBreaks {
+		opts.PageBreaks = breaks
+	}
+	return bookBody(files, opts)
+}
+
+func (bookFormat) Dependencies(source []byte, baseDir string) []string {
+	_, files, err := parseBook(source, baseDir)
+	if err != nil {
+		return nil
+	}
+	return bookDependencies(files)
+}
+
+func parseBook(source []byte, baseDir string) (FrontMatter, []string, error) {
+	fm, body := splitFrontMatter(source)
+
+	var files []string
+	for _, line := range splitLines(body) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		path := resolvePath(filepath.FromSlash(line), baseDir)
+		matches := []string{path}
+		if strings.ContainsAny(line, "*?[") {
+			var err error
+			matches, err = filepath.Glob(path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("book: invalid pattern %q: %w", line, err)
+			}
+			sort.Strings(matches)
+		}
+
+		for _, m := range matches {
+			if strings.EqualFold(filepath.Ext(m), ".book") {
+				return nil, nil, fmt.Errorf("book: %s is a book itself; books can't be nested", line)
+			}
+		}
+		files = append(files, matches...)
+	}
+
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("book lists no files")
+	}
+
+	return fm, files, nil
+}
+
+// BookFiles returns the files listed in a .book manifest.
+func BookFiles(filePath string) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	_, files, err := parseBook(data, filepath.Dir(filePath))
+	return files, err
+}
+
+// BookToHTML renders several files into one document, in order.
+func BookToHTML(files []string, opts Options) (string, error) {
+	body, err := bookBody(files, opts)
+	if err != nil {
+		return "", err
+	}
+	return documentHTML(body, opts)
+}
+
+// bookBody renders and postprocesses every file with its own base
+// directory, so relative images and links resolve as they do when the file
+// is rendered alone. Headings are numbered and listed in tables of contents
+// across all files.
+func bookBody(files []string, opts Options) (string, error) {
+	separator := "\n"
+	if opts.PageBreaks {
+		separator = pageBreakHTML
+	}
+
+	headings := newHeadingState()
+
+	parts := make([]string, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", filepath.Base(file), err)
+		}
+
+		member := opts
+		member.BaseDir = filepath.Dir(file)
+		member.headings = headings
+
+		body, err := formatFor(file, opts.Formats).Body(data, member)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		parts = append(parts, postprocess(body, member))
+	}
+
+	return headings.expandTOCs(strings.Join(parts, separator))
+}
+
+// bookDependencies returns the member files and everything they reference.
+func bookDependencies(files []string) []string {
+	var deps []string
+	for _, file := range files {
+		deps = append(deps, file)
+		if fileDeps, err := Dependencies(file); err == nil {
+			deps = append(deps, fileDeps...)
+		}
+	}
+	return deps
+}
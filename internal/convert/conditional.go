@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+type condFrame struct {
+	line     int
+	matched  bool
+	inElse   bool
+	parentOn bool
+}
+
+// filterConditionals drops the content of docmd:if blocks whose condition
+// does not match tags. A condition is a space separated list of key=value or
+// key!=value terms that must all hold; values may list alternatives
+// separated by commas.
+func filterConditionals(source []byte, tags map[string]string) ([]byte, error) {
+	var (
+		out   strings.Builder
+		stack []condFrame
+		fence fenceTracker
+	)
+
+	active := true
+
+	for i, line := range splitLines(source) {
+		lineNo := i + 1
+
+		if fence.inFence(line) {
+			if active {
+				out.WriteString(line)
+			}
+			continue
+		}
+
+		rest, ok := lineDirective(line, docmdDirective)
+		if !ok {
+			if active {
+				out.WriteString(line)
+			}
+			continue
+		}
+
+		directive, args, _ := strings.Cut(rest, " ")
+
+		switch directive {
+		case "if":
+			matched, err := evalCondition(args, tags)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			stack = append(stack, condFrame{line: lineNo, matched: matched, parentOn: active})
+			active = active && matched
+
+		case "else":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: docmd:else without docmd:if", lineNo)
+			}
+			top := &stack[len(stack)-1]
+			if top.inElse {
+				return nil, fmt.Errorf("line %d: duplicate docmd:else", lineNo)
+			}
+			top.inElse = true
+			active = top.parentOn && !top.matched
+
+		case "endif":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: docmd:endif without docmd:if", lineNo)
+			}
+			active = stack[len(stack)-1].parentOn
+			stack = stack[:len(stack)-1]
+
+		default:
+			if active {
+				out.WriteString(line)
+			}
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("line %d: docmd:if is never closed", stack[len(stack)-1].line)
+	}
+
+	return []byte(out.String()), nil
+}
+
+func evalCondition(expr string, tags map[string]string) (bool, error) {
+	terms := strings.Fields(expr)
+	if len(terms) == 0 {
+		return false, fmt.Errorf("docmd:if needs a condition")
+	}
+
+	for _, term := range terms {
+		negate := false
+		key, values, ok := strings.Cut(term, "!=")
+		if ok {
+			negate = true
+		} else if key, values, ok = strings.Cut(term, "="); !ok {
+			return false, fmt.Errorf("invalid condition %q (expected key=value)", term)
+		}
+
+		tag, hasTag := tags[key]
+		matched := false
+		if hasTag {
+			for _, v := range strings.Split(values, ",") {
+				if strings.TrimSpace(v) == tag {
+					matched = true
+					break
+				}
+			}
+		}
+
+		if matched == negate {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,78 @@
+package convert
+
+import "testing"
+
+func TestFilterConditionals(t *testing.T) {
+	tags := map[string]string{"audience": "internal", "os": "linux"}
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			"matching block kept",
+			"a\n<!-- docmd:if audience=internal -->\nb\n<!-- docmd:endif -->\nc\n",
+			"a\nb\nc\n",
+		},
+		{
+			"else branch",
+			"<!-- docmd:if audience=external -->\nx\n<!-- docmd:else -->\ny\n<!-- docmd:endif -->\n",
+			"y\n",
+		},
+		{
+			"negation and alternatives",
+			"<!-- docmd:if audience!=external os=mac,linux -->\nx\n<!-- docmd:endif -->\n",
+			"x\n",
+		},
+		{
+			"missing tag doesn't match",
+			"<!-- docmd:if team=ops -->\nx\n<!-- docmd:endif -->\n",
+			"",
+		},
+		{
+			"nested in a dropped block",
+			"<!-- docmd:if audience=external -->\n<!-- docmd:if os=linux -->\nx\n<!-- docmd:endif -->\n<!-- docmd:endif -->\n",
+			"",
+		},
+		{
+			"directives in code fences are text",
+			"```\n<!-- docmd:if audience=external -->\n```\n",
+			"```\n<!-- docmd:if audience=external -->\n```\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterConditionals([]byte(tt.source), tags)
+			if err != nil {
+				t.Fatalf("filterConditionals: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterConditionalsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"unclosed if", "<!-- docmd:if a=b -->\nx\n"},
+		{"endif without if", "x\n<!-- docmd:endif -->\n"},
+		{"else without if", "<!-- docmd:else -->\n"},
+		{"duplicate else", "<!-- docmd:if a=b -->\n<!-- docmd:else -->\n<!-- docmd:else -->\n<!-- docmd:endif -->\n"},
+		{"empty condition", "<!-- docmd:if -->\n<!-- docmd:endif -->\n"},
+		{"invalid term", "<!-- docmd:if audience -->\n<!-- docmd:endif -->\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := filterConditionals([]byte(tt.source), nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
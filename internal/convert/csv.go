@@ -0,0 +1,249 @@
+package convert
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// expandDataTables replaces <!-- csv: path [options] --> lines with GFM
+// tables built from CSV or TSV files relative to baseDir.
+//
+// Supported options:
+//
+//	header=false        the first row is data, not column names
+//	columns=name,3      columns to include, by header name or 1-based index
+//	max-rows=20         maximum number of data rows
+//	format=%.2f         fmt verb for numeric cells; col:%.1f entries apply
+//	                    to a single column
+//	delimiter=tab       field separator (default: comma, tab for .tsv)
+func expandDataTables(source []byte, baseDir string) ([]byte, error) {
+	var (
+		out   strings.Builder
+		fence fenceTracker
+	)
+
+	for i, line := range splitLines(source) {
+		if fence.inFence(line) {
+			out.WriteString(line)
+			continue
+		}
+
+		args, ok := lineDirective(line, csvDirective)
+		if !ok {
+			out.WriteString(line)
+			continue
+		}
+
+		table, err := renderDataTable(args, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		out.WriteString(table)
+	}
+
+	return []byte(out.String()), nil
+}
+
+func dataTablePath(args string, baseDir string) (string, bool) {
+	positional, _, err := directiveArgs(args)
+	if err != nil || len(positional) == 0 {
+		return "", false
+	}
+	path := positional[0]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return path, true
+}
+
+func renderDataTable(args string, baseDir string) (string, error) {
+	positional, opts, err := directiveArgs(args)
+	if err != nil {
+		return "", fmt.Errorf("csv: %w", err)
+	}
+	if len(positional) != 1 {
+		return "", fmt.Errorf("csv: expected a single file path")
+	}
+
+	path, _ := dataTablePath(args, baseDir)
+
+	records, err := readRecords(path, opts["delimiter"])
+	if err != nil {
+		return "", fmt.Errorf("csv %s: %w", positional[0], err)
+	}
+	if len(records) == 0 {
+		return "", fmt.Errorf("csv %s: file is empty", positional[0])
+	}
+
+	var header []string
+	rows := records
+	if opts["header"] != "false" {
+		header, rows = records[0], records[1:]
+	} else {
+		for i := range records[0] {
+			header = append(header, fmt.Sprintf("Column %d", i+1))
+		}
+	}
+
+	columns, err := selectColumns(header, opts["columns"])
+	if err != nil {
+		return "", fmt.Errorf("csv %s: %w", positional[0], err)
+	}
+
+	omitted := 0
+	if v, ok := opts["max-rows"]; ok {
+		maxRows, err := strconv.Atoi(v)
+		if err != nil || maxRows < 0 {
+			return "", fmt.Errorf("csv %s: invalid max-rows %q", positional[0], v)
+		}
+		if len(rows) > maxRows {
+			omitted = len(rows) - maxRows
+			rows = rows[:maxRows]
+		}
+	}
+
+	defaultFormat, columnFormats := parseFormats(opts["format"])
+
+	cells := make([][]string, len(rows))
+	numeric := make([]bool, len(columns))
+	for c := range numeric {
+		numeric[c] = len(rows) > 0
+	}
+
+	for r, row := range rows {
+		cells[r] = make([]string, len(columns))
+		for c, col := range columns {
+			value := ""
+			if col < len(row) {
+				value = strings.TrimSpace(row[col])
+			}
+
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				format := defaultFormat
+				if f, ok := columnFormats[header[col]]; ok {
+					format = f
+				}
+				if format != "" {
+					value = fmt.Sprintf(format, n)
+				}
+			} else if value != "" {
+				numeric[c] = false
+			}
+
+			cells[r][c] = value
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n|")
+	for _, col := range columns {
+		sb.WriteString(" " + escapeCell(header[col]) + " |")
+	}
+	sb.WriteString("\n|")
+	for c := range columns {
+		if numeric[c] {
+			sb.WriteString(" ---: |")
+		} else {
+			sb.WriteString(" --- |")
+		}
+	}
+	sb.WriteString("\n")
+	for _, row := range cells {
+		sb.WriteString("|")
+		for _, cell := range row {
+			sb.WriteString(" " + escapeCell(cell) + " |")
+		}
+		sb.WriteString("\n")
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&sb, "\n*%d more row(s) not shown.*\n", omitted)
+	}
+	sb.WriteString("\n")
+
+	return sb.String(), nil
+}
+
+func readRecords(path string, delimiter string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	switch {
+	case delimiter == "tab" || (delimiter == "" && strings.EqualFold(filepath.Ext(path), ".tsv")):
+		r.Comma = '\t'
+	case delimiter == "":
+	case len([]rune(delimiter)) == 1:
+		r.Comma = []rune(delimiter)[0]
+	default:
+		return nil, fmt.Errorf("invalid delimiter %q", delimiter)
+	}
+
+	return r.ReadAll()
+}
+
+func selectColumns(header []string, spec string) ([]int, error) {
+	if spec == "" {
+		columns := make([]int, len(header))
+		for i := range header {
+			columns[i] = i
+		}
+		return columns, nil
+	}
+
+	var columns []int
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		found := -1
+		for i, h := range header {
+			if strings.TrimSpace(h) == name {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			if n, err := strconv.Atoi(name); err == nil && n >= 1 && n <= len(header) {
+				found = n - 1
+			}
+		}
+		if found < 0 {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns = append(columns, found)
+	}
+
+	return columns, nil
+}
+
+func parseFormats(spec string) (string, map[string]string) {
+	var defaultFormat string
+	columnFormats := make(map[string]string)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if col, format, ok := strings.Cut(entry, ":"); ok && !strings.HasPrefix(entry, "%") {
+			columnFormats[col] = format
+		} else {
+			defaultFormat = entry
+		}
+	}
+
+	return defaultFormat, columnFormats
+}
+
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
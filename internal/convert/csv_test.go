@@ -0,0 +1,106 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandDataTables(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"people.csv": "name,age,note\nAda,36,a|b\nAlan,41.5,\nGrace,85,\n",
+		"plain.tsv":  "x\ty\n1\t2\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		directive string
+		want      []string
+	}{
+		{
+			"whole file",
+			"people.csv",
+			[]string{"| name | age | note |", "| --- | ---: | --- |", `| Ada | 36 | a\|b |`},
+		},
+		{
+			"columns by name and index",
+			"people.csv columns=age,1",
+			[]string{"| age | name |", "| 36 | Ada |"},
+		},
+		{
+			"max rows",
+			"people.csv max-rows=1",
+			[]string{"| Ada |", "*2 more row(s) not shown.*"},
+		},
+		{
+			"column format",
+			"people.csv columns=age format=age:%.1f",
+			[]string{"| 36.0 |", "| 41.5 |"},
+		},
+		{
+			"no header",
+			"people.csv header=false max-rows=1",
+			[]string{"| Column 1 | Column 2 | Column 3 |", "| name | age | note |"},
+		},
+		{
+			"tsv",
+			"plain.tsv",
+			[]string{"| x | y |", "| 1 | 2 |"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "<!-- csv: " + tt.directive + " -->\n"
+			out, err := expandDataTables([]byte(source), dir)
+			if err != nil {
+				t.Fatalf("expandDataTables: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("output %q doesn't contain %q", out, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandDataTablesErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.csv"), []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, directive := range []string{
+		"missing.csv",
+		"a.csv columns=c",
+		"a.csv max-rows=-1",
+		"a.csv delimiter=ab",
+		`"a.csv`,
+	} {
+		t.Run(directive, func(t *testing.T) {
+			source := "<!-- csv: " + directive + " -->\n"
+			if _, err := expandDataTables([]byte(source), dir); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestExpandDataTablesSkipsFences(t *testing.T) {
+	source := "```\n<!-- csv: missing.csv -->\n```\n"
+	out, err := expandDataTables([]byte(source), t.TempDir())
+	if err != nil {
+		t.Fatalf("expandDataTables: %v", err)
+	}
+	if string(out) != source {
+		t.Errorf("got %q, want the source unchanged", out)
+	}
+}
@@ -0,0 +1,55 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dependencies returns the files referenced by filePath, such as snippets,
+// data tables or includes, so that callers can re-render it when one of
+// them changes.
+func Dependencies(filePath string) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	lister, ok := FormatFor(filePath).(dependencyLister)
+	if !ok {
+		return nil, nil
+	}
+
+	var deps []string
+	seen := make(map[string]bool)
+	for _, path := range lister.Dependencies(data, filepath.Dir(filePath)) {
+		path, err := filepath.Abs(path)
+		if err != nil || seen[path] {
+			continue
+		}
+		seen[path] = true
+		deps = append(deps, path)
+	}
+
+	return deps, nil
+}
+
+func directiveDependencies(source []byte, baseDir string) []string {
+	var deps []string
+	var fence fenceTracker
+	for _, line := range splitLines(source) {
+		if fence.inFence(line) {
+			continue
+		}
+
+		if ref, ok := lineDirective(line, snippetDirective); ok {
+			deps = append(deps, snippetPath(ref, baseDir))
+		} else if args, ok := lineDirective(line, csvDirective); ok {
+			if path, ok := dataTablePath(args, baseDir); ok {
+				deps = append(deps, path)
+			}
+		}
+	}
+
+	return deps
+}
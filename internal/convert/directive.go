@@ -0,0 +1,130 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fenceTracker follows fenced code blocks line by line so that directives
+// quoted inside code examples are left alone.
+type fenceTracker struct {
+	marker string
+}
+
+func (f *fenceTracker) inFence(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if f.marker != "" {
+		if strings.HasPrefix(trimmed, f.marker) && strings.TrimSpace(strings.TrimLeft(trimmed, f.marker[:1])) == "" {
+			f.marker = ""
+		}
+		return true
+	}
+
+	for _, m := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, m) {
+			n := len(trimmed) - len(strings.TrimLeft(trimmed, m[:1]))
+			f.marker = strings.Repeat(m[:1], n)
+			return true
+		}
+	}
+
+	return false
+}
+
+// htmlComment returns the trimmed text of a line that consists of a single
+// HTML comment.
+func htmlComment(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "<!--") || !strings.HasSuffix(trimmed, "-->") {
+		return "", false
+	}
+	inner := strings.TrimSuffix(strings.TrimPrefix(trimmed, "<!--"), "-->")
+	if strings.Contains(inner, "-->") {
+		return "", false
+	}
+	return strings.TrimSpace(inner), true
+}
+
+// Names of the <!-- name: args --> directives. docmd: prefixes the
+// conditional and table of contents directives.
+const (
+	docmdDirective   = "docmd"
+	snippetDirective = "snippet"
+	csvDirective     = "csv"
+)
+
+var directiveNames = []string{docmdDirective, snippetDirective, csvDirective}
+
+// isDirective reports whether a line is a directive comment, which is
+// consumed by docmd rather than rendered.
+func isDirective(line string) bool {
+	for _, name := range directiveNames {
+		if _, ok := lineDirective(line, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lineDirective returns the arguments of a <!-- name: args --> line.
+func lineDirective(line string, name string) (string, bool) {
+	comment, ok := htmlComment(line)
+	if !ok {
+		return "", false
+	}
+	args, ok := strings.CutPrefix(comment, name+":")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(args), true
+}
+
+func splitLines(source []byte) []string {
+	return strings.SplitAfter(string(source), "\n")
+}
+
+// directiveArgs splits directive arguments into positional values and
+// key=value options. Values may be double-quoted to include spaces.
+func directiveArgs(s string) ([]string, map[string]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		quoted  bool
+		started bool
+	)
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if started {
+				fields = append(fields, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if started {
+		fields = append(fields, current.String())
+	}
+
+	var positional []string
+	options := make(map[string]string)
+	for _, f := range fields {
+		if key, value, ok := strings.Cut(f, "="); ok && key != "" {
+			options[key] = value
+		} else {
+			positional = append(positional, f)
+		}
+	}
+
+	return positional, options, nil
+}
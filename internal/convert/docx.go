@@ -0,0 +1,877 @@
+package convert
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DocxPage is the page layout of a generated .docx file, in points.
+type DocxPage struct {
+	// Width and Height in portrait orientation. Zero means A4.
+	Width     float64
+	Height    float64
+	Landscape bool
+
+	// Margins are top, right, bottom and left. Nil means one inch.
+	Margins *[4]float64
+}
+
+const (
+	twipsPerPoint = 20
+	emuPerPoint   = 12700
+	emuPerPixel   = 9525
+)
+
+var (
+	whitespace      = regexp.MustCompile(`[ \t\r\n]+`)
+	backgroundColor = regexp.MustCompile(`background-color:\s*#([0-9a-fA-F]{6})`)
+	bookmarkName    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,39}$`)
+)
+
+var imageTypes = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpeg",
+	"image/gif":  "gif",
+}
+
+// HTMLToDocx converts a document produced by this package into a Word
+// file. It understands the HTML the converters emit (headings, lists,
+// tables, code blocks, blockquotes, images as data URIs and the docmd
+// classes) and mirrors the stylesheet used for Google Docs.
+func HTMLToDocx(doc string, page DocxPage) ([]byte, error) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	if page.Width == 0 || page.Height == 0 {
+		page.Width, page.Height = 595.3, 841.9
+	}
+	margins := [4]float64{72, 72, 72, 72}
+	if page.Margins != nil {
+		margins = *page.Margins
+	}
+	width, height := page.Width, page.Height
+	if page.Landscape {
+		width, height = height, width
+	}
+
+	w := &docxWriter{textWidth: width - margins[1] - margins[3]}
+	w.out = &w.body
+
+	body := findElement(root, atom.Body)
+	if body == nil {
+		body = root
+	}
+	w.blocks(body, blockContext{})
+	w.flush()
+
+	orient := ""
+	if page.Landscape {
+		orient = ` w:orient="landscape"`
+	}
+	fmt.Fprintf(&w.body, `<w:sectPr><w:pgSz w:w="%d" w:h="%d"%s/><w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`,
+		twips(width), twips(height), orient,
+		twips(margins[0]), twips(margins[1]), twips(margins[2]), twips(margins[3]))
+
+	return w.pack(title(root))
+}
+
+func twips(points float64) int {
+	return int(points*twipsPerPoint + 0.5)
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func title(root *html.Node) string {
+	if t := findElement(root, atom.Title); t != nil {
+		return textContent(t)
+	}
+	if h := findElement(root, atom.H1); h != nil {
+		return strings.TrimSpace(textContent(h))
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+type docxRel struct {
+	id, kind, target string
+	external         bool
+}
+
+type docxMedia struct {
+	name string
+	data []byte
+}
+
+type docxList struct {
+	ordered bool
+	start   int
+}
+
+// blockContext is inherited by the paragraphs inside a block element.
+type blockContext struct {
+	style  string
+	indent int
+	item   *listItem
+}
+
+// listItem numbers the first paragraph of a list item; later paragraphs
+// of the item are only indented.
+type listItem struct {
+	numID int
+	level int
+	used  bool
+}
+
+type runFormat struct {
+	bold, italic, strike, code, underline, highlight bool
+	vertAlign                                        string
+	link                                             string
+	anchor                                           string
+}
+
+type paragraph struct {
+	props        string
+	runs         bytes.Buffer
+	trimLeading  bool
+	pendingSpace bool
+	pageBreak    bool
+	bookmarkName string
+}
+
+type docxWriter struct {
+	body      bytes.Buffer
+	out       *bytes.Buffer
+	cur       *paragraph
+	rels      []docxRel
+	media     []docxMedia
+	lists     []docxList
+	level     int
+	images    int
+	bookmarks int
+	textWidth float64
+}
+
+// blocks writes the children of n, grouping inline content into
+// paragraphs.
+func (w *docxWriter) blocks(n *html.Node, ctx blockContext) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			if w.cur == nil && strings.TrimSpace(c.Data) == "" {
+				continue
+			}
+			w.inline(c, runFormat{}, ctx)
+			continue
+		}
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if isBlock(c.DataAtom) {
+			w.block(c, ctx)
+		} else {
+			w.inline(c, runFormat{}, ctx)
+		}
+	}
+}
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Ul, atom.Ol, atom.Li, atom.Pre, atom.Blockquote, atom.Table,
+		atom.Hr, atom.Div, atom.Section, atom.Dl, atom.Dt, atom.Dd,
+		atom.Header, atom.Footer, atom.Article, atom.Figure, atom.Figcaption:
+		return true
+	}
+	return false
+}
+
+func (w *docxWriter) block(n *html.Node, ctx blockContext) {
+	w.flush()
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		ctx.style = "Heading" + strconv.Itoa(level)
+		w.open(ctx)
+		if id := attr(n, "id"); bookmarkName.MatchString(id) {
+			w.cur.bookmarkName = id
+		}
+		w.inlineChildren(n, runFormat{}, ctx)
+		w.flush()
+
+	case atom.P:
+		if strings.Contains(attr(n, "style"), "page-break-before") {
+			w.open(ctx)
+			w.cur.pageBreak = true
+			w.flush()
+			return
+		}
+		if hasClass(n, "docmd-banner") {
+			ctx.style = "Banner"
+		}
+		w.blocks(n, ctx)
+		w.flush()
+
+	case atom.Hr:
+		w.open(ctx)
+		w.cur.props += `<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="CCCCCC"/></w:pBdr>`
+		w.flush()
+
+	case atom.Ul, atom.Ol:
+		start := 1
+		if s, err := strconv.Atoi(attr(n, "start")); err == nil {
+			start = s
+		}
+		w.lists = append(w.lists, docxList{ordered: n.DataAtom == atom.Ol, start: start})
+		numID := len(w.lists)
+
+		w.level++
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Li {
+				item := ctx
+				item.item = &listItem{numID: numID, level: min(w.level-1, 8)}
+				w.blocks(c, item)
+				w.flush()
+			}
+		}
+		w.level--
+
+	case atom.Pre:
+		style := "Code"
+		if hasClass(n, "docmd-output") {
+			style = "Output"
+		}
+		text := strings.TrimSuffix(textContent(n), "\n")
+		for _, line := range strings.Split(text, "\n") {
+			lineCtx := ctx
+			lineCtx.style = style
+			w.open(lineCtx)
+			w.text(strings.TrimRight(line, "\r"), runFormat{}, true)
+			w.flush()
+		}
+
+	case atom.Blockquote:
+		ctx.style = "Quote"
+		w.blocks(n, ctx)
+
+	case atom.Table:
+		w.table(n, ctx)
+
+	case atom.Dt:
+		w.open(ctx)
+		w.inlineChildren(n, runFormat{bold: true}, ctx)
+		w.flush()
+
+	case atom.Dd:
+		ctx.indent += 720
+		w.blocks(n, ctx)
+
+	default:
+		w.blocks(n, ctx)
+	}
+
+	w.flush()
+}
+
+// open starts a paragraph unless one is already open.
+func (w *docxWriter) open(ctx blockContext) {
+	if w.cur != nil {
+		return
+	}
+
+	var props strings.Builder
+	if ctx.style != "" {
+		fmt.Fprintf(&props, `<w:pStyle w:val="%s"/>`, ctx.style)
+	}
+	if item := ctx.item; item != nil {
+		if !item.used {
+			fmt.Fprintf(&props, `<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, item.level, item.numID)
+			item.used = true
+		} else {
+			fmt.Fprintf(&props, `<w:ind w:left="%d"/>`, 720*(item.level+1)+ctx.indent)
+		}
+	} else if ctx.indent > 0 {
+		fmt.Fprintf(&props, `<w:ind w:left="%d"/>`, ctx.indent)
+	}
+
+	w.cur = &paragraph{props: props.String(), trimLeading: true}
+}
+
+func (w *docxWriter) flush() {
+	p := w.cur
+	if p == nil {
+		return
+	}
+	w.cur = nil
+
+	w.out.WriteString("<w:p>")
+	if p.props != "" {
+		w.out.WriteString("<w:pPr>" + p.props + "</w:pPr>")
+	}
+	if p.bookmarkName != "" {
+		w.bookmarks++
+		fmt.Fprintf(w.out, `<w:bookmarkStart w:id="%d" w:name="%s"/>`, w.bookmarks, p.bookmarkName)
+	}
+	if p.pageBreak {
+		w.out.WriteString(`<w:r><w:br w:type="page"/></w:r>`)
+	}
+	w.out.Write(p.runs.Bytes())
+	if p.bookmarkName != "" {
+		fmt.Fprintf(w.out, `<w:bookmarkEnd w:id="%d"/>`, w.bookmarks)
+	}
+	w.out.WriteString("</w:p>")
+}
+
+func (w *docxWriter) inlineChildren(n *html.Node, f runFormat, ctx blockContext) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && isBlock(c.DataAtom) {
+			w.block(c, ctx)
+			continue
+		}
+		w.inline(c, f, ctx)
+	}
+}
+
+func (w *docxWriter) inline(n *html.Node, f runFormat, ctx blockContext) {
+	if n.Type == html.TextNode {
+		w.open(ctx)
+		w.text(n.Data, f, false)
+		return
+	}
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Strong, atom.B:
+		f.bold = true
+	case atom.Em, atom.I, atom.Cite:
+		f.italic = true
+	case atom.Del, atom.S, atom.Strike:
+		f.strike = true
+	case atom.U, atom.Ins:
+		f.underline = true
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		f.code = true
+	case atom.Mark:
+		f.highlight = true
+	case atom.Sup:
+		f.vertAlign = "superscript"
+	case atom.Sub:
+		f.vertAlign = "subscript"
+	case atom.A:
+		href := attr(n, "href")
+		if strings.HasPrefix(href, "#") {
+			f.anchor = href[1:]
+		} else if href != "" {
+			f.link = w.rel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink", href, true)
+		}
+	case atom.Br:
+		w.open(ctx)
+		w.cur.runs.WriteString("<w:r><w:br/></w:r>")
+		w.cur.trimLeading = true
+		w.cur.pendingSpace = false
+		return
+	case atom.Img:
+		w.open(ctx)
+		w.image(n, f)
+		return
+	case atom.Input:
+		if attr(n, "type") == "checkbox" {
+			box := "☐"
+			for _, a := range n.Attr {
+				if a.Key == "checked" {
+					box = "☑"
+				}
+			}
+			w.open(ctx)
+			w.text(box, f, true)
+		}
+		return
+	case atom.Script, atom.Style:
+		return
+	}
+
+	w.inlineChildren(n, f, ctx)
+}
+
+// text writes a run. Unless preserve is set, whitespace is collapsed as a
+// browser would.
+func (w *docxWriter) text(s string, f runFormat, preserve bool) {
+	p := w.cur
+	if !preserve {
+		s = whitespace.ReplaceAllString(s, " ")
+		if p.trimLeading {
+			s = strings.TrimLeft(s, " ")
+		}
+		// A trailing space is held back so that none is left at the end
+		// of the paragraph or before a line break.
+		trailing := strings.HasSuffix(s, " ")
+		s = strings.TrimSuffix(s, " ")
+		if s == "" {
+			p.pendingSpace = p.pendingSpace || trailing
+			return
+		}
+		if strings.HasPrefix(s, " ") {
+			p.pendingSpace = false
+		}
+		defer func() { p.pendingSpace = trailing }()
+	}
+	if p.pendingSpace {
+		p.runs.WriteString(`<w:r><w:t xml:space="preserve"> </w:t></w:r>`)
+		p.pendingSpace = false
+	}
+	if s == "" {
+		return
+	}
+	p.trimLeading = false
+
+	var run bytes.Buffer
+	run.WriteString("<w:r>")
+	if props := f.props(); props != "" {
+		run.WriteString("<w:rPr>" + props + "</w:rPr>")
+	}
+	run.WriteString(`<w:t xml:space="preserve">`)
+	xml.EscapeText(&run, []byte(s))
+	run.WriteString("</w:t></w:r>")
+
+	switch {
+	case f.link != "":
+		fmt.Fprintf(&p.runs, `<w:hyperlink r:id="%s">%s</w:hyperlink>`, f.link, run.String())
+	case f.anchor != "":
+		fmt.Fprintf(&p.runs, `<w:hyperlink w:anchor="%s">%s</w:hyperlink>`, xmlAttr(f.anchor), run.String())
+	default:
+		p.runs.Write(run.Bytes())
+	}
+}
+
+func (f runFormat) props() string {
+	var sb strings.Builder
+	if f.link != "" || f.anchor != "" {
+		sb.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+	} else if f.code {
+		sb.WriteString(`<w:rStyle w:val="CodeChar"/>`)
+	}
+	if f.bold {
+		sb.WriteString("<w:b/>")
+	}
+	if f.italic {
+		sb.WriteString("<w:i/>")
+	}
+	if f.strike {
+		sb.WriteString("<w:strike/>")
+	}
+	if f.underline {
+		sb.WriteString(`<w:u w:val="single"/>`)
+	}
+	if f.highlight {
+		sb.WriteString(`<w:highlight w:val="yellow"/>`)
+	}
+	if f.vertAlign != "" {
+		fmt.Fprintf(&sb, `<w:vertAlign w:val="%s"/>`, f.vertAlign)
+	}
+	return sb.String()
+}
+
+func xmlAttr(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return strings.ReplaceAll(buf.String(), `"`, "&#34;")
+}
+
+func (w *docxWriter) rel(kind, target string, external bool) string {
+	id := fmt.Sprintf("rId%d", len(w.rels)+10)
+	w.rels = append(w.rels, docxRel{id: id, kind: kind, target: target, external: external})
+	return id
+}
+
+// image embeds a data URI image. Other images can't be fetched here and
+// are replaced by their alt text.
+func (w *docxWriter) image(n *html.Node, f runFormat) {
+	src := attr(n, "src")
+	alt := attr(n, "alt")
+
+	data, ext, ok := decodeDataURI(src)
+	if !ok {
+		if alt == "" {
+			alt = src
+		}
+		w.text("["+alt+"]", f, true)
+		return
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		w.text("["+alt+"]", f, true)
+		return
+	}
+
+	w.images++
+	name := fmt.Sprintf("image%d.%s", w.images, ext)
+	w.media = append(w.media, docxMedia{name: name, data: data})
+	rID := w.rel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", "media/"+name, false)
+
+	cx := int64(cfg.Width) * emuPerPixel
+	cy := int64(cfg.Height) * emuPerPixel
+	if maxWidth := int64(w.textWidth * emuPerPoint); cx > maxWidth && cx > 0 {
+		cy = cy * maxWidth / cx
+		cx = maxWidth
+	}
+
+	if w.cur.pendingSpace {
+		w.text("", runFormat{}, true)
+	}
+	fmt.Fprintf(&w.cur.runs, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">`+
+		`<wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d" descr="%s"/>`+
+		`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`+
+		`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, w.images, w.images, xmlAttr(alt), w.images, name, rID, cx, cy)
+	w.cur.trimLeading = false
+}
+
+func decodeDataURI(src string) ([]byte, string, bool) {
+	rest, ok := strings.CutPrefix(src, "data:")
+	if !ok {
+		return nil, "", false
+	}
+	meta, payload, ok := strings.Cut(rest, ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return nil, "", false
+	}
+	ext, ok := imageTypes[strings.TrimSuffix(meta, ";base64")]
+	if !ok {
+		return nil, "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", false
+	}
+	return data, ext, true
+}
+
+func (w *docxWriter) table(n *html.Node, ctx blockContext) {
+	var rows []*html.Node
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Tr:
+				rows = append(rows, c)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(c)
+			}
+		}
+	}
+	collect(n)
+
+	columns := 0
+	for _, row := range rows {
+		count := 0
+		for c := row.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Td || c.DataAtom == atom.Th {
+				count += max(1, atoiOr(attr(c, "colspan"), 1))
+			}
+		}
+		columns = max(columns, count)
+	}
+	if columns == 0 {
+		return
+	}
+
+	colWidth := twips(w.textWidth) / columns
+	if ctx.indent > 0 {
+		colWidth = (twips(w.textWidth) - ctx.indent) / columns
+	}
+
+	w.out.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/>`)
+	if ctx.indent > 0 {
+		fmt.Fprintf(w.out, `<w:tblInd w:w="%d" w:type="dxa"/>`, ctx.indent)
+	}
+	w.out.WriteString(`</w:tblPr><w:tblGrid>`)
+	for i := 0; i < columns; i++ {
+		fmt.Fprintf(w.out, `<w:gridCol w:w="%d"/>`, colWidth)
+	}
+	w.out.WriteString(`</w:tblGrid>`)
+
+	for _, row := range rows {
+		w.out.WriteString("<w:tr>")
+		if row.Parent != nil && row.Parent.DataAtom == atom.Thead {
+			w.out.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		for c := row.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom != atom.Td && c.DataAtom != atom.Th {
+				continue
+			}
+			span := max(1, atoiOr(attr(c, "colspan"), 1))
+
+			w.out.WriteString("<w:tc><w:tcPr>")
+			fmt.Fprintf(w.out, `<w:tcW w:w="%d" w:type="dxa"/>`, colWidth*span)
+			if span > 1 {
+				fmt.Fprintf(w.out, `<w:gridSpan w:val="%d"/>`, span)
+			}
+			if m := backgroundColor.FindStringSubmatch(attr(c, "style")); m != nil {
+				fmt.Fprintf(w.out, `<w:shd w:val="clear" w:color="auto" w:fill="%s"/>`, strings.ToUpper(m[1]))
+			}
+			w.out.WriteString("</w:tcPr>")
+
+			w.cell(c, blockContext{})
+
+			w.out.WriteString("</w:tc>")
+		}
+		w.out.WriteString("</w:tr>")
+	}
+	w.out.WriteString("</w:tbl>")
+
+	// Word needs a paragraph between adjacent tables.
+	w.out.WriteString("<w:p/>")
+}
+
+// cell writes the content of a table cell, which must end with a
+// paragraph.
+func (w *docxWriter) cell(n *html.Node, ctx blockContext) {
+	parent, level := w.out, w.level
+	var buf bytes.Buffer
+	w.out, w.level = &buf, 0
+
+	if n.DataAtom == atom.Th {
+		w.open(ctx)
+		w.inlineChildren(n, runFormat{bold: true}, ctx)
+	} else {
+		w.blocks(n, ctx)
+	}
+	w.flush()
+
+	if !bytes.HasSuffix(buf.Bytes(), []byte("</w:p>")) && !bytes.HasSuffix(buf.Bytes(), []byte("<w:p/>")) {
+		buf.WriteString("<w:p/>")
+	}
+
+	w.out, w.level = parent, level
+	w.out.Write(buf.Bytes())
+}
+
+func atoiOr(s string, fallback int) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	return fallback
+}
+
+func (w *docxWriter) pack(docTitle string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxPackageRels},
+		{"docProps/core.xml", fmt.Sprintf(docxCoreProps, xmlAttr(docTitle))},
+		{"word/document.xml", docxDocumentHead + w.body.String() + docxDocumentTail},
+		{"word/styles.xml", docxStyles},
+		{"word/numbering.xml", w.numbering()},
+		{"word/_rels/document.xml.rels", w.documentRels()},
+	}
+
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write([]byte(f.content)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range w.media {
+		fw, err := zw.Create("word/media/" + m.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(m.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write docx: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (w *docxWriter) documentRels() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	for _, r := range w.rels {
+		mode := ""
+		if r.external {
+			mode = ` TargetMode="External"`
+		}
+		fmt.Fprintf(&sb, `<Relationship Id="%s" Type="%s" Target="%s"%s/>`, r.id, r.kind, xmlAttr(r.target), mode)
+	}
+	sb.WriteString(`</Relationships>`)
+	return sb.String()
+}
+
+// numbering defines a bullet and a numbered list style and one instance
+// per list, so that every ordered list starts counting anew.
+func (w *docxWriter) numbering() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
+
+	bullets := []string{"•", "◦", "▪"}
+	formats := []string{"decimal", "lowerLetter", "lowerRoman"}
+	for abstract := 0; abstract < 2; abstract++ {
+		fmt.Fprintf(&sb, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, abstract)
+		for lvl := 0; lvl < 9; lvl++ {
+			format, text := "bullet", bullets[lvl%3]
+			if abstract == 1 {
+				format, text = formats[lvl%3], fmt.Sprintf("%%%d.", lvl+1)
+			}
+			fmt.Fprintf(&sb, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/>`+
+				`<w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				lvl, format, text, 720*(lvl+1))
+		}
+		sb.WriteString(`</w:abstractNum>`)
+	}
+
+	for i, l := range w.lists {
+		abstract := 0
+		if l.ordered {
+			abstract = 1
+		}
+		fmt.Fprintf(&sb, `<w:num w:numId="%d"><w:abstractNumId w:val="%d"/>`, i+1, abstract)
+		if l.ordered {
+			for lvl := 0; lvl < 9; lvl++ {
+				fmt.Fprintf(&sb, `<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="%d"/></w:lvlOverride>`, lvl, l.start)
+			}
+		}
+		sb.WriteString(`</w:num>`)
+	}
+
+	sb.WriteString(`</w:numbering>`)
+	return sb.String()
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="png" ContentType="image/png"/>
+<Default Extension="jpeg" ContentType="image/jpeg"/>
+<Default Extension="gif" ContentType="image/gif"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+const docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+const docxCoreProps = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>%s</dc:title>
+<dc:creator>docmd</dc:creator>
+</cp:coreProperties>`
+
+const docxDocumentHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+	`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" ` +
+	`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+	`xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"><w:body>`
+
+const docxDocumentTail = `</w:body></w:document>`
+
+// docxStyles mirrors the stylesheet in wrapDocument.
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Arial" w:hAnsi="Arial" w:eastAsia="Arial" w:cs="Arial"/><w:sz w:val="22"/><w:szCs w:val="22"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="400" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:sz w:val="40"/><w:szCs w:val="40"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:sz w:val="32"/><w:szCs w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="320" w:after="80"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:color w:val="434343"/><w:sz w:val="28"/><w:szCs w:val="28"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="280" w:after="80"/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:color w:val="666666"/><w:sz w:val="24"/><w:szCs w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:color w:val="666666"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:i/><w:color w:val="666666"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F4F4F4"/><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New" w:cs="Courier New"/><w:sz w:val="20"/><w:szCs w:val="20"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Output"><w:name w:val="Output"/><w:basedOn w:val="Code"/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="DDDDDD"/></w:pBdr><w:shd w:val="clear" w:color="auto" w:fill="FFFFFF"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="12" w:color="CCCCCC"/></w:pBdr><w:ind w:left="300"/></w:pPr><w:rPr><w:color w:val="666666"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Banner"><w:name w:val="Banner"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="FFF4E5"/></w:pPr><w:rPr><w:color w:val="8A5300"/><w:sz w:val="20"/><w:szCs w:val="20"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Inline Code"/><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New" w:cs="Courier New"/><w:shd w:val="clear" w:color="auto" w:fill="F4F4F4"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="1155CC"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders>` +
+	`<w:top w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:left w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/>` +
+	`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:right w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/>` +
+	`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/>` +
+	`</w:tblBorders><w:tblCellMar><w:top w:w="80" w:type="dxa"/><w:left w:w="100" w:type="dxa"/><w:bottom w:w="80" w:type="dxa"/><w:right w:w="100" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+</w:styles>`
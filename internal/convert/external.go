@@ -0,0 +1,63 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const defaultExternalTimeout = 30 * time.Second
+
+// ExternalFormat renders sources with an external command that reads the
+// source on stdin and writes HTML to stdout.
+type ExternalFormat struct {
+	Extension string
+	Command   []string
+	Timeout   time.Duration
+}
+
+func (f ExternalFormat) Name() string {
+	if len(f.Command) == 0 {
+		return "external"
+	}
+	return f.Command[0]
+}
+
+func (f ExternalFormat) Extensions() []string { return []string{f.Extension} }
+
+func (f ExternalFormat) Body(source []byte, opts Options) (string, error) {
+	if len(f.Command) == 0 {
+		return "", fmt.Errorf("no converter command configured for %s", f.Extension)
+	}
+
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = defaultExternalTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.Command[0], f.Command[1:]...)
+	cmd.Dir = opts.BaseDir
+	cmd.Stdin = bytes.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("converter %s timed out after %s", f.Name(), timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("converter %s failed: %w: %s", f.Name(), err, msg)
+		}
+		return "", fmt.Errorf("converter %s failed: %w", f.Name(), err)
+	}
+
+	return htmlBody(stdout.String()), nil
+}
@@ -0,0 +1,90 @@
+package convert
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Format renders one kind of source file to an HTML body fragment. The
+// fragment is wrapped into the final document by FileToHTML so that every
+// format gets the same post-processing.
+type Format interface {
+	Name() string
+	Extensions() []string
+	Body(source []byte, opts Options) (string, error)
+}
+
+// dependencyLister is implemented by formats whose sources can pull in
+// other files, so that watch mode can follow them.
+type dependencyLister interface {
+	Dependencies(source []byte, baseDir string) []string
+}
+
+var formats []Format
+
+func RegisterFormat(f Format) {
+	formats = append(formats, f)
+}
+
+func init() {
+	RegisterFormat(markdownFormat{})
+	RegisterFormat(notebookFormat{})
+	RegisterFormat(asciidocFormat{})
+	RegisterFormat(bookFormat{})
+}
+
+// FormatFor returns the format registered for the extension of filePath.
+// Files with unknown extensions are treated as markdown.
+func FormatFor(filePath string) Format {
+	return formatFor(filePath, nil)
+}
+
+func formatFor(filePath string, extra []Format) Format {
+	ext := strings.ToLower(filepath.Ext(filePath))
+
+	for _, f := range extra {
+		for _, e := range f.Extensions() {
+			if strings.ToLower(e) == ext {
+				return f
+			}
+		}
+	}
+
+	// Configured formats, in extra, take precedence over all registered
+	// ones. Among registered formats the latest registration wins.
+	for i := len(formats) - 1; i >= 0; i-- {
+		for _, e := range formats[i].Extensions() {
+			if strings.ToLower(e) == ext {
+				return formats[i]
+			}
+		}
+	}
+
+	return markdownFormat{}
+}
+
+type markdownFormat struct{}
+
+func (markdownFormat) Name() string { return "markdown" }
+
+func (markdownFormat) Extensions() []string {
+	return []string{".md", ".markdown", ".mdown", ".mkd"}
+}
+
+func (markdownFormat) Body(source []byte, opts Options) (string, error) {
+	return markdownBody(source, opts)
+}
+
+func (markdownFormat) Dependencies(source []byte, baseDir string) []string {
+	return directiveDependencies(source, baseDir)
+}
+
+type notebookFormat struct{}
+
+func (notebookFormat) Name() string { return "notebook" }
+
+func (notebookFormat) Extensions() []string { return []string{".ipynb"} }
+
+func (notebookFormat) Body(source []byte, opts Options) (string, error) {
+	return notebookBody(source, opts)
+}
@@ -0,0 +1,116 @@
+package convert
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FrontMatter holds the flat key: value pairs of a YAML front matter block.
+// Nested structures are not supported; their lines are ignored.
+type FrontMatter map[string]string
+
+func (fm FrontMatter) Bool(key string) (bool, bool) {
+	v, ok := fm[key]
+	if !ok {
+		return false, false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		switch strings.ToLower(v) {
+		case "yes", "on":
+			return true, true
+		case "no", "off":
+			return false, true
+		}
+		return false, false
+	}
+	return b, true
+}
+
+func (fm FrontMatter) Int(key string) (int, bool) {
+	v, ok := fm[key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	return n, err == nil
+}
+
+func (fm FrontMatter) Float(key string) (float64, bool) {
+	v, ok := fm[key]
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	return f, err == nil
+}
+
+// ReadFrontMatter returns the front matter of a source file.
+func ReadFrontMatter(filePath string) (FrontMatter, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	fm, _ := splitFrontMatter(data)
+	return fm, nil
+}
+
+// splitFrontMatter separates a leading --- delimited front matter block from
+// the document body.
+func splitFrontMatter(source []byte) (FrontMatter, []byte) {
+	fm := make(FrontMatter)
+
+	s := string(source)
+	if !strings.HasPrefix(s, "---\n") && !strings.HasPrefix(s, "---\r\n") {
+		return fm, source
+	}
+
+	rest := s[strings.Index(s, "\n")+1:]
+	var block []string
+	for offset := 0; offset <= len(rest); {
+		end := strings.Index(rest[offset:], "\n")
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+		line = strings.TrimRight(line, "\r")
+
+		if line == "---" || line == "..." {
+			for _, l := range block {
+				parseFrontMatterLine(fm, l)
+			}
+			if end < 0 {
+				return fm, nil
+			}
+			return fm, []byte(rest[offset+end+1:])
+		}
+		block = append(block, line)
+
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+
+	// No closing delimiter, so this was a thematic break.
+	return make(FrontMatter), source
+}
+
+func parseFrontMatterLine(fm FrontMatter, line string) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+		return
+	}
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return
+	}
+	value = strings.TrimSpace(value)
+	if i := strings.Index(value, " #"); i >= 0 && !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+		value = strings.TrimSpace(value[:i])
+	}
+	if len(value) >= 2 && (value[0] == '"' && value[len(value)-1] == '"' || value[0] == '\'' && value[len(value)-1] == '\'') {
+		value = value[1 : len(value)-1]
+	}
+	fm[strings.TrimSpace(key)] = value
+}
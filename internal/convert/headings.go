@@ -0,0 +1,278 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+var tocDirective = regexp.MustCompile(`^<!--\s*docmd:toc(?:\s+depth=(\d+))?\s*-->\s*$`)
+
+const tocPlaceholder = "<!-- docmd:toc-placeholder %d -->\n"
+
+type headingEntry struct {
+	level  int
+	id     string
+	number string
+	title  string
+}
+
+// headingState is shared by the files of a book, so that their headings
+// are numbered, identified and listed in tables of contents as one
+// document.
+type headingState struct {
+	counters [7]int
+	entries  []headingEntry
+	ids      map[string]bool
+
+	// tocs holds the depth of each table of contents, which are expanded
+	// once all files are rendered.
+	tocs []int
+}
+
+func newHeadingState() *headingState {
+	return &headingState{ids: make(map[string]bool)}
+}
+
+// expandTOCs replaces the table of contents placeholders in the rendered
+// book.
+func (s *headingState) expandTOCs(body string) (string, error) {
+	for i, depth := range s.tocs {
+		doc := ast.NewDocument()
+		doc.AppendChild(doc, buildTOC(s.entries, depth))
+
+		var buf bytes.Buffer
+		r := goldmark.New(goldmark.WithRendererOptions(html.WithXHTML())).Renderer()
+		if err := r.Render(&buf, nil, doc); err != nil {
+			return "", fmt.Errorf("table of contents: %w", err)
+		}
+		body = strings.Replace(body, fmt.Sprintf(tocPlaceholder, i), buf.String(), 1)
+	}
+	return body, nil
+}
+
+// bookIDs generates heading IDs that are unique across a book. renamed maps
+// the IDs a file's headings would have had on their own to the ones they
+// got, so the file's links can follow.
+type bookIDs struct {
+	state   *headingState
+	local   parser.IDs
+	renamed map[string]string
+}
+
+func newBookIDs(state *headingState) *bookIDs {
+	return &bookIDs{
+		state:   state,
+		local:   parser.NewContext().IDs(),
+		renamed: make(map[string]string),
+	}
+}
+
+func (b *bookIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	local := string(b.local.Generate(value, kind))
+	id := local
+	for i := 1; b.state.ids[id]; i++ {
+		id = fmt.Sprintf("%s-%d", local, i)
+	}
+	b.state.ids[id] = true
+	if id != local {
+		b.renamed[local] = id
+	}
+	return []byte(id)
+}
+
+func (b *bookIDs) Put(value []byte) {
+	b.local.Put(value)
+	b.state.ids[string(value)] = true
+}
+
+// headingTransformer numbers headings and expands <!-- docmd:toc -->
+// directives into a table of contents. Links to headings within the
+// document pick up the same numbers. With a shared state, as for the files
+// of a book, tables of contents are left as placeholders for expandTOCs.
+type headingTransformer struct {
+	number  bool
+	from    int
+	state   *headingState
+	renamed map[string]string
+}
+
+func (t *headingTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	state := t.state
+	if state == nil {
+		state = newHeadingState()
+	}
+	counters := &state.counters
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+
+		entry := headingEntry{
+			level: heading.Level,
+			title: string(heading.Text(source)),
+		}
+		if id, ok := heading.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				entry.id = string(b)
+			}
+		}
+
+		if t.number && heading.Level >= t.from {
+			counters[heading.Level]++
+			for l := heading.Level + 1; l < len(counters); l++ {
+				counters[l] = 0
+			}
+			parts := make([]string, 0, heading.Level-t.from+1)
+			for l := t.from; l <= heading.Level; l++ {
+				parts = append(parts, strconv.Itoa(counters[l]))
+			}
+			entry.number = strings.Join(parts, ".")
+			heading.InsertBefore(heading, heading.FirstChild(), ast.NewString([]byte(entry.number+" ")))
+		}
+
+		state.entries = append(state.entries, entry)
+		return ast.WalkSkipChildren, nil
+	})
+
+	byID := make(map[string]headingEntry, len(state.entries))
+	for _, e := range state.entries {
+		if e.id != "" {
+			byID[e.id] = e
+		}
+	}
+
+	var tocs []ast.Node
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.HTMLBlock:
+			if tocDirective.Match(bytes.TrimSpace(blockText(node, source))) {
+				tocs = append(tocs, node)
+			}
+		case *ast.Link:
+			dest := string(node.Destination)
+			if !strings.HasPrefix(dest, "#") {
+				return ast.WalkContinue, nil
+			}
+			if id, ok := t.renamed[dest[1:]]; ok {
+				dest = "#" + id
+				node.Destination = []byte(dest)
+			}
+			e, ok := byID[dest[1:]]
+			if !ok {
+				return ast.WalkContinue, nil
+			}
+			label := string(node.Text(source))
+			switch {
+			case node.ChildCount() == 0:
+				node.AppendChild(node, ast.NewString([]byte(strings.TrimSpace(e.number+" "+e.title))))
+			case e.number != "" && label == e.title:
+				node.InsertBefore(node, node.FirstChild(), ast.NewString([]byte(e.number+" ")))
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, toc := range tocs {
+		depth := 6
+		if m := tocDirective.FindSubmatch(bytes.TrimSpace(blockText(toc.(*ast.HTMLBlock), source))); m != nil && len(m[1]) > 0 {
+			depth, _ = strconv.Atoi(string(m[1]))
+		}
+		if t.state == nil {
+			toc.Parent().ReplaceChild(toc.Parent(), toc, buildTOC(state.entries, depth))
+			continue
+		}
+		placeholder := ast.NewString([]byte(fmt.Sprintf(tocPlaceholder, len(state.tocs))))
+		placeholder.SetCode(true)
+		toc.Parent().ReplaceChild(toc.Parent(), toc, placeholder)
+		state.tocs = append(state.tocs, depth)
+	}
+}
+
+func blockText(block *ast.HTMLBlock, source []byte) []byte {
+	var buf bytes.Buffer
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		buf.Write(seg.Value(source))
+	}
+	return buf.Bytes()
+}
+
+// buildTOC builds nested lists of links to the headings. The document title
+// (the only H1, if there is exactly one) is left out.
+func buildTOC(entries []headingEntry, depth int) ast.Node {
+	h1s := 0
+	for _, e := range entries {
+		if e.level == 1 {
+			h1s++
+		}
+	}
+
+	minLevel := 7
+	var included []headingEntry
+	for _, e := range entries {
+		if (e.level == 1 && h1s == 1) || e.level > depth || e.id == "" {
+			continue
+		}
+		included = append(included, e)
+		if e.level < minLevel {
+			minLevel = e.level
+		}
+	}
+
+	root := ast.NewList('-')
+	if len(included) == 0 {
+		return root
+	}
+
+	stack := []*ast.List{root}
+	levels := []int{minLevel}
+	var lastItem *ast.ListItem
+
+	for _, e := range included {
+		for e.level > levels[len(levels)-1] && lastItem != nil {
+			nested := ast.NewList('-')
+			lastItem.AppendChild(lastItem, nested)
+			stack = append(stack, nested)
+			levels = append(levels, levels[len(levels)-1]+1)
+		}
+		for len(stack) > 1 && e.level < levels[len(levels)-1] {
+			stack = stack[:len(stack)-1]
+			levels = levels[:len(levels)-1]
+		}
+
+		link := ast.NewLink()
+		link.Destination = []byte("#" + e.id)
+		link.AppendChild(link, ast.NewString([]byte(strings.TrimSpace(e.number+" "+e.title))))
+
+		block := ast.NewTextBlock()
+		block.AppendChild(block, link)
+
+		item := ast.NewListItem(2)
+		item.AppendChild(item, block)
+
+		list := stack[len(stack)-1]
+		list.AppendChild(list, item)
+		lastItem = item
+	}
+
+	return root
+}
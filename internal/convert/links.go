@@ -0,0 +1,69 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// LinkRef is a link found in a markdown file.
+type LinkRef struct {
+	File   string
+	Line   int
+	Target string
+}
+
+// MarkdownLinks returns the links of a markdown file and the anchors of its
+// headings.
+func MarkdownLinks(filePath string, source []byte) ([]LinkRef, map[string]bool) {
+	tree := parseMarkdownTree(source)
+
+	var links []LinkRef
+	anchors := make(map[string]bool)
+
+	ast.Walk(tree.doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Heading:
+			if id, ok := n.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					anchors[string(b)] = true
+				}
+			}
+		case *ast.Link:
+			links = append(links, LinkRef{File: filePath, Line: tree.line(n), Target: string(n.Destination)})
+		case *ast.AutoLink:
+			links = append(links, LinkRef{File: filePath, Line: tree.line(n), Target: string(n.URL(tree.body))})
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return links, anchors
+}
+
+// HeadingAnchors returns the anchors of the headings of a markdown file.
+func HeadingAnchors(filePath string) (map[string]bool, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	_, anchors := MarkdownLinks(filePath, data)
+	return anchors, nil
+}
+
+// LocalTarget splits a link to a local file into the file, resolved
+// against baseDir, and its fragment. It reports false for URLs and
+// in-document anchors.
+func LocalTarget(ref string, baseDir string) (path string, fragment string, ok bool) {
+	if !isLocalRef(ref) || strings.HasPrefix(ref, "#") {
+		return "", "", false
+	}
+	path, fragment, _ = strings.Cut(ref, "#")
+	return resolveRef(path, baseDir), fragment, true
+}
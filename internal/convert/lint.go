@@ -0,0 +1,230 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Issue is a construct in a source file that won't come out right in
+// Google Docs.
+type Issue struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+}
+
+// maxListDepth is the deepest list nesting that survives the import with
+// readable indentation.
+const maxListDepth = 3
+
+var htmlTagName = regexp.MustCompile(`^\s*<([A-Za-z][A-Za-z0-9-]*)`)
+
+// LintFile checks a file for constructs Google Docs can't represent. Only
+// markdown is checked; the files of a book are checked one by one.
+func LintFile(filePath string) ([]Issue, error) {
+	if FormatFor(filePath).Name() == "book" {
+		files, err := BookFiles(filePath)
+		if err != nil {
+			return nil, err
+		}
+		var issues []Issue
+		for _, f := range files {
+			fileIssues, err := LintFile(f)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, fileIssues...)
+		}
+		return issues, nil
+	}
+
+	if FormatFor(filePath).Name() != "markdown" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return LintMarkdown(filePath, data), nil
+}
+
+// markdownTree is a markdown file parsed for checks that report lines.
+type markdownTree struct {
+	doc       ast.Node
+	body      []byte
+	firstLine int
+}
+
+func parseMarkdownTree(source []byte) markdownTree {
+	_, body := splitFrontMatter(source)
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+	return markdownTree{
+		doc:       md.Parser().Parse(text.NewReader(body)),
+		body:      body,
+		firstLine: 1 + bytes.Count(source[:len(source)-len(body)], []byte("\n")),
+	}
+}
+
+// line returns the line of the source file a node starts on.
+func (t markdownTree) line(n ast.Node) int {
+	return t.firstLine + bytes.Count(t.body[:t.offset(n)], []byte("\n"))
+}
+
+// LintMarkdown walks the markdown AST of source and reports raw HTML that
+// is dropped, missing images, images without alt text, deeply nested lists
+// and skipped heading levels.
+func LintMarkdown(filePath string, source []byte) []Issue {
+	tree := parseMarkdownTree(source)
+	body := tree.body
+	baseDir := filepath.Dir(filePath)
+
+	var issues []Issue
+	report := func(n ast.Node, format string, args ...any) {
+		issues = append(issues, Issue{
+			File:    filePath,
+			Line:    tree.line(n),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	lastLevel := 0
+	ast.Walk(tree.doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Heading:
+			if lastLevel > 0 && n.Level > lastLevel+1 {
+				report(n, "heading level skips from H%d to H%d", lastLevel, n.Level)
+			}
+			lastLevel = n.Level
+
+		case *ast.List:
+			depth := 0
+			for p := n.Parent(); p != nil; p = p.Parent() {
+				if _, ok := p.(*ast.List); ok {
+					depth++
+				}
+			}
+			if depth == maxListDepth {
+				report(n, "list nested %d levels deep; deeper levels may lose their indentation", depth+1)
+			}
+
+		case *ast.Image:
+			dest := string(n.Destination)
+			if len(bytes.TrimSpace(n.Text(body))) == 0 {
+				report(n, "image has no alt text: %s", dest)
+			}
+			if isLocalRef(dest) {
+				if _, err := os.Stat(resolveRef(dest, baseDir)); err != nil {
+					report(n, "image not found: %s", dest)
+				}
+			}
+
+		case *ast.HTMLBlock:
+			if n.Lines().Len() > 0 {
+				line := n.Lines().At(0)
+				if what := droppedHTML(line.Value(body)); what != "" {
+					report(n, "raw HTML %s is dropped from the doc", what)
+				}
+			}
+			return ast.WalkSkipChildren, nil
+
+		case *ast.RawHTML:
+			if n.Segments.Len() > 0 {
+				seg := n.Segments.At(0)
+				if what := droppedHTML(seg.Value(body)); what != "" {
+					report(n, "raw HTML %s is dropped from the doc", what)
+				}
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return issues
+}
+
+// droppedHTML describes raw HTML, which the renderer omits, for an issue.
+// Directive comments are meant to be consumed, and closing tags were
+// reported with their opening tag.
+func droppedHTML(html []byte) string {
+	trimmed := bytes.TrimSpace(html)
+	if bytes.HasPrefix(trimmed, []byte("<!--")) {
+		if isDirective(string(trimmed)) {
+			return ""
+		}
+		return "comment"
+	}
+	if bytes.HasPrefix(trimmed, []byte("</")) {
+		return ""
+	}
+	if m := htmlTagName.FindSubmatch(trimmed); m != nil {
+		return "<" + strings.ToLower(string(m[1])) + ">"
+	}
+	return "markup"
+}
+
+// offset returns the byte offset where a node starts, falling back to the
+// end of the preceding text or the start of the enclosing block for nodes
+// without a position of their own.
+func (t markdownTree) offset(n ast.Node) int {
+	if offset, ok := ownOffset(n); ok {
+		return offset
+	}
+	if prev, ok := n.PreviousSibling().(*ast.Text); ok {
+		if prev.SoftLineBreak() || prev.HardLineBreak() {
+			if i := bytes.IndexByte(t.body[prev.Segment.Stop:], '\n'); i >= 0 {
+				return prev.Segment.Stop + i + 1
+			}
+		}
+		return prev.Segment.Stop
+	}
+	if n.Parent() != nil {
+		return t.offset(n.Parent())
+	}
+	return 0
+}
+
+func ownOffset(n ast.Node) (int, bool) {
+	switch n := n.(type) {
+	case *ast.Text:
+		return n.Segment.Start, true
+	case *ast.RawHTML:
+		if n.Segments.Len() > 0 {
+			return n.Segments.At(0).Start, true
+		}
+	case *ast.Document:
+		return 0, true
+	}
+
+	if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+		return n.Lines().At(0).Start, true
+	}
+
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if offset, ok := ownOffset(c); ok {
+			return offset, true
+		}
+	}
+	return 0, false
+}
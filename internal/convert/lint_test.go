@@ -0,0 +1,56 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDroppedHTML(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{"<u>", "<u>"},
+		{"  <TABLE class=\"x\">", "<table>"},
+		{"</u>", ""},
+		{"<!-- note -->", "comment"},
+		{"<!-- docmd:if audience=internal -->", ""},
+		{"<!-- docmd:toc depth=2 -->", ""},
+		{"<!-- snippet: main.go#setup -->", ""},
+		{"<!-- csv: data.csv max-rows=5 -->", ""},
+		{"<!DOCTYPE html>", "markup"},
+	}
+
+	for _, tt := range tests {
+		if got := droppedHTML([]byte(tt.html)); got != tt.want {
+			t.Errorf("droppedHTML(%q) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestLintMarkdownRawHTML(t *testing.T) {
+	source := `# Title
+
+<!-- snippet: main.go -->
+
+<!-- csv: data.csv -->
+
+Some <u>x</u> and H<sub>2</sub>O.
+
+<table><tr><td>a</td></tr></table>
+`
+
+	var got []string
+	for _, issue := range LintMarkdown("doc.md", []byte(source)) {
+		got = append(got, issue.String())
+	}
+
+	want := []string{
+		"doc.md:7: raw HTML <u> is dropped from the doc",
+		"doc.md:7: raw HTML <sub> is dropped from the doc",
+		"doc.md:9: raw HTML <table> is dropped from the doc",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
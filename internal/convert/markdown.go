@@ -4,29 +4,149 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
 )
 
-func MarkdownToHTML(source []byte) (string, error) {
+type Options struct {
+	Banner  *Banner
+	Tags    map[string]string
+	BaseDir string
+
+	// VaultRoot is the Obsidian vault wikilinks and embeds are resolved
+	// against. Without one they are left as written.
+	VaultRoot string
+
+	// ResolveLink maps a local file a link points to onto the URL of its
+	// doc. Links it can't resolve are left unchanged.
+	ResolveLink func(filePath string) (string, bool)
+
+	// Formats are consulted before the built-in formats, so configured
+	// converters can handle additional extensions.
+	Formats []Format
+
+	// NumberHeadings prefixes headings from level NumberFrom (default 1)
+	// down with section numbers such as 1.2.3. Front matter can enable it
+	// with number_headings and number_headings_from.
+	NumberHeadings bool
+	NumberFrom     int
+
+	// PageBreaks starts every file of a book on a new page.
+	PageBreaks bool
+
+	// Notebook options
+	HideCode    bool
+	HideOutputs bool
+
+	// headings is shared by the files of a book and the cells of a
+	// notebook.
+	headings *headingState
+}
+
+func MarkdownToHTML(source []byte, opts Options) (string, error) {
+	body, err := markdownBody(source, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return wrapDocument(body, opts)
+}
+
+func markdownBody(source []byte, opts Options) (string, error) {
+	fm, source := splitFrontMatter(source)
+	if on, ok := fm.Bool("number_headings"); ok && !opts.NumberHeadings {
+		opts.NumberHeadings = on
+	}
+	if from, ok := fm.Int("number_headings_from"); ok && opts.NumberFrom == 0 {
+		opts.NumberFrom = from
+	}
+
+	source, err := filterConditionals(source, opts.Tags)
+	if err != nil {
+		return "", fmt.Errorf("conditional blocks: %w", err)
+	}
+
+	if opts.VaultRoot != "" {
+		source, err = expandObsidian(source, opts, newVault(opts.VaultRoot), 0, make(map[string]bool))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var raw rawBlocks
+	source, err = extractCallouts(source, &raw)
+	if err != nil {
+		return "", err
+	}
+
+	source, err = expandSnippets(source, opts.BaseDir)
+	if err != nil {
+		return "", err
+	}
+
+	source, err = expandDataTables(source, opts.BaseDir)
+	if err != nil {
+		return "", err
+	}
+
+	headings := &headingTransformer{number: opts.NumberHeadings, from: opts.NumberFrom, state: opts.headings}
+	return renderMarkdown(source, &raw, headings)
+}
+
+func renderMarkdown(source []byte, raw *rawBlocks, headings *headingTransformer) (string, error) {
 	var buf bytes.Buffer
 
+	if headings.from < 1 {
+		headings.from = 1
+	}
+
 	md := goldmark.New(
 		goldmark.WithExtensions(
 			extension.GFM,
 		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithASTTransformers(util.Prioritized(headings, 500)),
+		),
 		goldmark.WithRendererOptions(
 			html.WithHardWraps(),
 			html.WithXHTML(),
 		),
 	)
 
-	if err := md.Convert(source, &buf); err != nil {
+	var parseOpts []parser.ParseOption
+	if headings.state != nil {
+		ids := newBookIDs(headings.state)
+		headings.renamed = ids.renamed
+		parseOpts = append(parseOpts, parser.WithContext(parser.NewContext(parser.WithIDs(ids))))
+	}
+
+	if err := md.Convert(source, &buf, parseOpts...); err != nil {
 		return "", fmt.Errorf("markdown conversion failed: %w", err)
 	}
 
+	return raw.restore(buf.String()), nil
+}
+
+func wrapDocument(body string, opts Options) (string, error) {
+	return documentHTML(postprocess(body, opts), opts)
+}
+
+// documentHTML wraps a postprocessed body into a complete document.
+func documentHTML(body string, opts Options) (string, error) {
+	if opts.Banner != nil {
+		var err error
+		body, err = opts.Banner.wrap(body)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	html := fmt.Sprintf(`<!DOCTYPE html>
 <html>
 <head>
@@ -37,21 +157,37 @@ func MarkdownToHTML(source []byte) (string, error) {
   pre { background-color: #f4f4f4; padding: 10px; overflow-x: auto; }
   pre code { padding: 0; background: none; }
   blockquote { border-left: 3px solid #ccc; margin-left: 0; padding-left: 15px; color: #666; }
+  .docmd-banner { background-color: #fff4e5; padding: 8px; color: #8a5300; font-size: 10pt; }
+  .docmd-output { background-color: #ffffff; border-left: 3px solid #ddd; }
 </style>
 </head>
 <body>
 %s
 </body>
-</html>`, buf.String())
+</html>`, body)
 
 	return html, nil
 }
 
-func FileToHTML(filePath string) (string, error) {
+func FileToHTML(filePath string, opts Options) (string, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	return MarkdownToHTML(data)
+	if opts.BaseDir == "" {
+		opts.BaseDir = filepath.Dir(filePath)
+	}
+
+	format := formatFor(filePath, opts.Formats)
+	body, err := format.Body(data, opts)
+	if err != nil {
+		return "", err
+	}
+
+	// Books postprocess each file against its own directory.
+	if _, ok := format.(bookFormat); ok {
+		return documentHTML(body, opts)
+	}
+	return wrapDocument(body, opts)
 }
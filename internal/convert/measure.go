@@ -0,0 +1,28 @@
+package convert
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	blockStart = regexp.MustCompile(`(?i)<(?:p|li|h[1-6]|tr|pre|blockquote|img|dt|dd)\b`)
+	anyTag     = regexp.MustCompile(`(?s)<style\b.*?</style>|<[^>]*>`)
+)
+
+// MeasureHTML returns the amount of text in a rendered document, counted in
+// non-space characters, and its number of blocks (paragraphs, headings, list
+// items, table rows, code blocks and images).
+func MeasureHTML(doc string) (chars int, blocks int) {
+	body := htmlBody(doc)
+	text := html.UnescapeString(anyTag.ReplaceAllString(body, " "))
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			chars++
+		}
+	}
+	blocks = len(blockStart.FindAllStringIndex(strings.ToLower(body), -1))
+	return chars, blocks
+}
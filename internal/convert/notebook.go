@@ -0,0 +1,165 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType string           `json:"cell_type"`
+	Source   multilineString  `json:"source"`
+	Outputs  []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                     `json:"output_type"`
+	Name       string                     `json:"name"`
+	Text       multilineString            `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	EName      string                     `json:"ename"`
+	EValue     string                     `json:"evalue"`
+	Traceback  []string                   `json:"traceback"`
+}
+
+// multilineString accepts both encodings nbformat allows for text: a single
+// string or a list of lines.
+type multilineString string
+
+func (m *multilineString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = multilineString(s)
+		return nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*m = multilineString(strings.Join(lines, ""))
+	return nil
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// NotebookToHTML renders a Jupyter notebook. Markdown cells go through the
+// regular markdown pipeline, code cells become code blocks and outputs are
+// rendered as preformatted text or embedded PNG images.
+func NotebookToHTML(data []byte, opts Options) (string, error) {
+	body, err := notebookBody(data, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return wrapDocument(body, opts)
+}
+
+func notebookBody(data []byte, opts Options) (string, error) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return "", fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	lang := nb.Metadata.LanguageInfo.Name
+	if lang == "" {
+		lang = nb.Metadata.KernelSpec.Language
+	}
+
+	// Cells share heading numbers, IDs and tables of contents like the
+	// files of a book, which expands them itself when the notebook is one.
+	own := opts.headings == nil
+	if own {
+		opts.headings = newHeadingState()
+	}
+
+	var body strings.Builder
+
+	for i, cell := range nb.Cells {
+		switch cell.CellType {
+		case "markdown":
+			rendered, err := markdownBody([]byte(cell.Source), opts)
+			if err != nil {
+				return "", fmt.Errorf("cell %d: %w", i+1, err)
+			}
+			body.WriteString(rendered)
+
+		case "code":
+			if !opts.HideCode && strings.TrimSpace(string(cell.Source)) != "" {
+				class := ""
+				if lang != "" {
+					class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(lang))
+				}
+				fmt.Fprintf(&body, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(string(cell.Source)))
+			}
+			if !opts.HideOutputs {
+				for _, output := range cell.Outputs {
+					body.WriteString(renderNotebookOutput(output))
+				}
+			}
+
+		case "raw":
+			if strings.TrimSpace(string(cell.Source)) != "" {
+				fmt.Fprintf(&body, "<pre>%s</pre>\n", html.EscapeString(string(cell.Source)))
+			}
+		}
+	}
+
+	if own {
+		return opts.headings.expandTOCs(body.String())
+	}
+	return body.String(), nil
+}
+
+func renderNotebookOutput(output notebookOutput) string {
+	switch output.OutputType {
+	case "stream":
+		return preformattedOutput(string(output.Text))
+
+	case "error":
+		text := strings.Join(output.Traceback, "\n")
+		if text == "" {
+			text = output.EName + ": " + output.EValue
+		}
+		return preformattedOutput(text)
+
+	case "execute_result", "display_data":
+		if raw, ok := output.Data["image/png"]; ok {
+			var encoded multilineString
+			if err := json.Unmarshal(raw, &encoded); err == nil {
+				png := strings.Join(strings.Fields(string(encoded)), "")
+				return fmt.Sprintf("<p><img src=\"data:image/png;base64,%s\" alt=\"output\"/></p>\n", png)
+			}
+		}
+		if raw, ok := output.Data["text/plain"]; ok {
+			var text multilineString
+			if err := json.Unmarshal(raw, &text); err == nil {
+				return preformattedOutput(string(text))
+			}
+		}
+	}
+
+	return ""
+}
+
+func preformattedOutput(text string) string {
+	text = strings.TrimRight(ansiEscape.ReplaceAllString(text, ""), "\n")
+	if text == "" {
+		return ""
+	}
+	return fmt.Sprintf("<pre class=\"docmd-output\">%s</pre>\n", html.EscapeString(text))
+}
@@ -0,0 +1,33 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotebookHeadingsSpanCells(t *testing.T) {
+	nb := `{"cells": [
+		{"cell_type": "markdown", "source": "<!-- docmd:toc -->\n\n# Intro"},
+		{"cell_type": "code", "source": "x = 1", "outputs": []},
+		{"cell_type": "markdown", "source": "# Intro\n\n## Details"}
+	], "metadata": {}}`
+
+	out, err := notebookBody([]byte(nb), Options{NumberHeadings: true})
+	if err != nil {
+		t.Fatalf("notebookBody: %v", err)
+	}
+
+	for _, want := range []string{
+		`id="intro">1 Intro`,
+		`id="intro-1">2 Intro`,
+		`id="details">2.1 Details`,
+		`href="#intro-1"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q doesn't contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "toc-placeholder") {
+		t.Errorf("output %q still has a TOC placeholder", out)
+	}
+}
@@ -0,0 +1,361 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	wikiEmbed   = regexp.MustCompile(`!\[\[([^\]\n]+)\]\]`)
+	wikiLink    = regexp.MustCompile(`\[\[([^\]\n]+)\]\]`)
+	calloutHead = regexp.MustCompile(`^>\s*\[!(\w+)\][+-]?\s*(.*)$`)
+	inlineCode  = regexp.MustCompile("`[^`\n]*`")
+)
+
+const maxEmbedDepth = 5
+
+// vault resolves Obsidian note and attachment names. Like Obsidian, a bare
+// name matches a file anywhere in the vault; paths are relative to the
+// vault root.
+type vault struct {
+	root  string
+	index map[string][]string
+}
+
+func newVault(root string) *vault {
+	return &vault{root: root}
+}
+
+func (v *vault) buildIndex() {
+	v.index = make(map[string][]string)
+	filepath.WalkDir(v.root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != v.root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := strings.ToLower(d.Name())
+		v.index[name] = append(v.index[name], path)
+		if strings.EqualFold(filepath.Ext(name), ".md") {
+			stem := strings.TrimSuffix(name, filepath.Ext(name))
+			v.index[stem] = append(v.index[stem], path)
+		}
+		return nil
+	})
+}
+
+// resolve returns the file a wikilink target refers to, preferring
+// candidates closest to fromDir.
+func (v *vault) resolve(target string, fromDir string) (string, bool) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", false
+	}
+
+	if strings.Contains(target, "/") {
+		for _, candidate := range []string{target, target + ".md"} {
+			path := filepath.Join(v.root, filepath.FromSlash(candidate))
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, true
+			}
+		}
+		return "", false
+	}
+
+	if v.index == nil {
+		v.buildIndex()
+	}
+
+	candidates := v.index[strings.ToLower(target)]
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	best := candidates[0]
+	for _, c := range candidates {
+		if filepath.Dir(c) == fromDir {
+			return c, true
+		}
+		if len(c) < len(best) {
+			best = c
+		}
+	}
+	return best, true
+}
+
+// expandObsidian rewrites Obsidian-specific syntax into plain markdown:
+// note embeds are inlined, attachment embeds become images and wikilinks
+// become links to the linked doc of the target note, or plain text when the
+// note isn't linked.
+func expandObsidian(source []byte, opts Options, v *vault, depth int, seen map[string]bool) ([]byte, error) {
+	var (
+		out   strings.Builder
+		fence fenceTracker
+	)
+
+	for _, line := range splitLines(source) {
+		if fence.inFence(line) {
+			out.WriteString(line)
+			continue
+		}
+
+		if !strings.Contains(line, "[[") {
+			out.WriteString(line)
+			continue
+		}
+
+		var err error
+		line = outsideInlineCode(line, func(text string) string {
+			text = wikiEmbed.ReplaceAllStringFunc(text, func(m string) string {
+				if err != nil {
+					return m
+				}
+				var replacement string
+				replacement, err = expandEmbed(wikiEmbed.FindStringSubmatch(m)[1], opts, v, depth, seen)
+				return replacement
+			})
+			return wikiLink.ReplaceAllStringFunc(text, func(m string) string {
+				return renderWikilink(wikiLink.FindStringSubmatch(m)[1], opts, v)
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		out.WriteString(line)
+	}
+
+	return []byte(out.String()), nil
+}
+
+func outsideInlineCode(line string, fn func(string) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range inlineCode.FindAllStringIndex(line, -1) {
+		sb.WriteString(fn(line[last:loc[0]]))
+		sb.WriteString(line[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(fn(line[last:]))
+	return sb.String()
+}
+
+func splitWikiTarget(inner string) (target, heading, alias string) {
+	target, alias, _ = strings.Cut(inner, "|")
+	target, heading, _ = strings.Cut(target, "#")
+	heading = strings.TrimPrefix(heading, "^")
+	return strings.TrimSpace(target), strings.TrimSpace(heading), strings.TrimSpace(alias)
+}
+
+func renderWikilink(inner string, opts Options, v *vault) string {
+	target, heading, alias := splitWikiTarget(inner)
+
+	text := alias
+	if text == "" {
+		text = target
+		if heading != "" {
+			if text == "" {
+				text = heading
+			} else {
+				text += " > " + heading
+			}
+		}
+	}
+	text = escapeLinkText(text)
+
+	if target == "" || opts.ResolveLink == nil {
+		return text
+	}
+
+	path, ok := v.resolve(target, opts.BaseDir)
+	if !ok {
+		return text
+	}
+
+	docURL, ok := opts.ResolveLink(path)
+	if !ok {
+		return text
+	}
+
+	return fmt.Sprintf("[%s](<%s>)", text, docURL)
+}
+
+func expandEmbed(inner string, opts Options, v *vault, depth int, seen map[string]bool) (string, error) {
+	target, heading, alias := splitWikiTarget(inner)
+
+	path, ok := v.resolve(target, opts.BaseDir)
+	if !ok {
+		return escapeLinkText(target), nil
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".md") {
+		alt := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if alias != "" && !isDigits(alias) {
+			alt = alias
+		}
+		return fmt.Sprintf("![%s](<%s>)", escapeLinkText(alt), filepath.ToSlash(path)), nil
+	}
+
+	if depth >= maxEmbedDepth || seen[path] {
+		return escapeLinkText(target), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("embed %s: %w", target, err)
+	}
+
+	_, content := splitFrontMatter(data)
+	if heading != "" {
+		content = extractSection(content, heading)
+	}
+
+	seen[path] = true
+	defer delete(seen, path)
+
+	nested := opts
+	nested.BaseDir = filepath.Dir(path)
+	expanded, err := expandObsidian(content, nested, v, depth+1, seen)
+	if err != nil {
+		return "", err
+	}
+
+	return "\n\n" + strings.TrimSpace(string(expanded)) + "\n\n", nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != 'x' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func escapeLinkText(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(s)
+}
+
+// extractSection returns the part of source under the given heading, up to
+// the next heading of the same or a higher level.
+func extractSection(source []byte, heading string) []byte {
+	var out strings.Builder
+	level := 0
+	var fence fenceTracker
+
+	for _, line := range splitLines(source) {
+		if fence.inFence(line) {
+			if level > 0 {
+				out.WriteString(line)
+			}
+			continue
+		}
+
+		if n, title := atxHeading(line); n > 0 {
+			if level > 0 && n <= level {
+				break
+			}
+			if level == 0 && strings.EqualFold(title, heading) {
+				level = n
+			}
+		}
+
+		if level > 0 {
+			out.WriteString(line)
+		}
+	}
+
+	return []byte(out.String())
+}
+
+func atxHeading(line string) (int, string) {
+	trimmed := strings.TrimRight(line, "\r\n")
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if n == 0 || n > 6 || (len(trimmed) > n && trimmed[n] != ' ') {
+		return 0, ""
+	}
+	return n, strings.TrimSpace(strings.TrimRight(strings.TrimSpace(trimmed[n:]), "#"))
+}
+
+// extractCallouts replaces Obsidian callouts (> [!note] Title) with
+// placeholders for rendered callout boxes.
+func extractCallouts(source []byte, raw *rawBlocks) ([]byte, error) {
+	var (
+		out   strings.Builder
+		fence fenceTracker
+	)
+
+	lines := splitLines(source)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if fence.inFence(line) {
+			out.WriteString(line)
+			continue
+		}
+
+		m := calloutHead.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if m == nil {
+			out.WriteString(line)
+			continue
+		}
+
+		var inner strings.Builder
+		for i+1 < len(lines) && strings.HasPrefix(lines[i+1], ">") {
+			i++
+			content := strings.TrimPrefix(lines[i], ">")
+			inner.WriteString(strings.TrimPrefix(content, " "))
+		}
+
+		body, err := renderMarkdown([]byte(inner.String()), raw, &headingTransformer{})
+		if err != nil {
+			return nil, err
+		}
+
+		out.WriteString(raw.hold(admonitionHTML(m[1], strings.TrimSpace(m[2]), body)))
+	}
+
+	return []byte(out.String()), nil
+}
+
+// rawBlocks holds pre-rendered HTML that must survive goldmark, which
+// escapes raw HTML. Blocks are replaced by placeholder paragraphs and
+// restored after rendering.
+type rawBlocks []string
+
+func (r *rawBlocks) hold(html string) string {
+	*r = append(*r, html)
+	return fmt.Sprintf("\ndocmd-raw-block-%d\n\n", len(*r)-1)
+}
+
+func (r rawBlocks) restore(html string) string {
+	for i, block := range r {
+		html = strings.Replace(html, fmt.Sprintf("<p>docmd-raw-block-%d</p>\n", i), block, 1)
+	}
+	return html
+}
+
+// FindVaultRoot returns the closest directory above filePath that contains
+// an .obsidian folder, or "" when the file is not inside a vault.
+func FindVaultRoot(filePath string) string {
+	dir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return ""
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".obsidian")); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
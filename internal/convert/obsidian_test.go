@@ -0,0 +1,27 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWikilinksNeedVault(t *testing.T) {
+	source := []byte("See [[Other note|the note]].\n")
+
+	out, err := markdownBody(source, Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("markdownBody: %v", err)
+	}
+	if !strings.Contains(out, "[[Other note|the note]]") {
+		t.Errorf("without a vault, output %q should keep the wikilink", out)
+	}
+
+	vault := t.TempDir()
+	out, err = markdownBody(source, Options{BaseDir: vault, VaultRoot: vault})
+	if err != nil {
+		t.Fatalf("markdownBody: %v", err)
+	}
+	if strings.Contains(out, "[[") || !strings.Contains(out, "the note") {
+		t.Errorf("in a vault, output %q should render the wikilink", out)
+	}
+}
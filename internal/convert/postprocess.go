@@ -0,0 +1,99 @@
+package convert
+
+import (
+	"encoding/base64"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	imgSrcAttr   = regexp.MustCompile(`(<img\b[^>]*?\bsrc=)("[^"]*"|'[^']*')`)
+	anchorHref   = regexp.MustCompile(`(<a\b[^>]*?\bhref=)("[^"]*"|'[^']*')`)
+	bodyContents = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+)
+
+// postprocess rewrites references that only make sense locally: images are
+// embedded as data URIs because Drive cannot fetch local files, and links to
+// other linked source files point at their docs.
+func postprocess(body string, opts Options) string {
+	body = imgSrcAttr.ReplaceAllStringFunc(body, func(tag string) string {
+		m := imgSrcAttr.FindStringSubmatch(tag)
+		src := html.UnescapeString(m[2][1 : len(m[2])-1])
+		if !isLocalRef(src) {
+			return tag
+		}
+		uri, ok := dataURI(resolveRef(src, opts.BaseDir))
+		if !ok {
+			return tag
+		}
+		return m[1] + `"` + uri + `"`
+	})
+
+	if opts.ResolveLink == nil {
+		return body
+	}
+
+	return anchorHref.ReplaceAllStringFunc(body, func(tag string) string {
+		m := anchorHref.FindStringSubmatch(tag)
+		href := html.UnescapeString(m[2][1 : len(m[2])-1])
+		if !isLocalRef(href) || strings.HasPrefix(href, "#") {
+			return tag
+		}
+		path, _, _ := strings.Cut(href, "#")
+		docURL, ok := opts.ResolveLink(resolveRef(path, opts.BaseDir))
+		if !ok {
+			return tag
+		}
+		return m[1] + `"` + html.EscapeString(docURL) + `"`
+	})
+}
+
+func isLocalRef(ref string) bool {
+	if ref == "" || strings.HasPrefix(ref, "//") {
+		return false
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return false
+	}
+	// A single letter scheme is a Windows drive, not a URL.
+	return len(u.Scheme) <= 1
+}
+
+func resolveRef(ref string, baseDir string) string {
+	ref, _, _ = strings.Cut(ref, "?")
+	if unescaped, err := url.PathUnescape(ref); err == nil {
+		ref = unescaped
+	}
+	return resolvePath(filepath.FromSlash(ref), baseDir)
+}
+
+func dataURI(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), true
+}
+
+// htmlBody returns the contents of the body element when html is a full
+// document, or html itself when it is a fragment.
+func htmlBody(doc string) string {
+	if m := bodyContents.FindStringSubmatch(doc); m != nil {
+		return m[1]
+	}
+	return doc
+}
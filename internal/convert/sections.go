@@ -0,0 +1,52 @@
+package convert
+
+import (
+	"strings"
+)
+
+// Section is part of a markdown document starting at a heading. Its source
+// carries the document's front matter so it renders with the same settings.
+type Section struct {
+	Title  string
+	Source []byte
+}
+
+// SplitSections cuts markdown source at every heading of the given level.
+// Content before the first such heading becomes a section with an empty
+// title, unless it is blank.
+func SplitSections(source []byte, level int) []Section {
+	_, body := splitFrontMatter(source)
+	frontMatter := source[:len(source)-len(body)]
+
+	var (
+		sections []Section
+		current  strings.Builder
+		title    string
+		fence    fenceTracker
+	)
+
+	flush := func() {
+		if title == "" && strings.TrimSpace(current.String()) == "" {
+			current.Reset()
+			return
+		}
+		src := make([]byte, 0, len(frontMatter)+current.Len())
+		src = append(src, frontMatter...)
+		src = append(src, current.String()...)
+		sections = append(sections, Section{Title: title, Source: src})
+		current.Reset()
+	}
+
+	for _, line := range splitLines(body) {
+		if !fence.inFence(line) {
+			if n, text := atxHeading(line); n == level {
+				flush()
+				title = text
+			}
+		}
+		current.WriteString(line)
+	}
+	flush()
+
+	return sections
+}
@@ -0,0 +1,201 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var snippetLanguages = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "tsx",
+	".jsx":   "jsx",
+	".rb":    "ruby",
+	".rs":    "rust",
+	".java":  "java",
+	".kt":    "kotlin",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".php":   "php",
+	".sh":    "bash",
+	".bash":  "bash",
+	".sql":   "sql",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".json":  "json",
+	".toml":  "toml",
+	".xml":   "xml",
+	".html":  "html",
+	".css":   "css",
+	".proto": "protobuf",
+	".swift": "swift",
+}
+
+// expandSnippets replaces <!-- snippet: path#L10-L42 --> and
+// <!-- snippet: path#region --> lines with fenced code blocks read from
+// files relative to baseDir. Regions are delimited in the source file by
+// docmd:region <name> and docmd:endregion markers.
+func expandSnippets(source []byte, baseDir string) ([]byte, error) {
+	var (
+		out   strings.Builder
+		fence fenceTracker
+	)
+
+	for i, line := range splitLines(source) {
+		if fence.inFence(line) {
+			out.WriteString(line)
+			continue
+		}
+
+		ref, ok := lineDirective(line, snippetDirective)
+		if !ok {
+			out.WriteString(line)
+			continue
+		}
+
+		block, err := renderSnippet(ref, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		out.WriteString(block)
+	}
+
+	return []byte(out.String()), nil
+}
+
+func snippetPath(ref string, baseDir string) string {
+	path, _, _ := strings.Cut(ref, "#")
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return path
+}
+
+func renderSnippet(ref string, baseDir string) (string, error) {
+	path := snippetPath(ref, baseDir)
+	_, selector, _ := strings.Cut(ref, "#")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("snippet %s: %w", ref, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	switch {
+	case selector == "":
+	case isLineSelector(selector):
+		lines, err = selectLines(lines, selector)
+	default:
+		lines, err = selectRegion(lines, selector)
+	}
+	if err != nil {
+		return "", fmt.Errorf("snippet %s: %w", ref, err)
+	}
+
+	code := strings.Join(dedent(lines), "\n")
+	lang := snippetLanguages[strings.ToLower(filepath.Ext(path))]
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	return fmt.Sprintf("%s%s\n%s\n%s\n", fence, lang, code, fence), nil
+}
+
+func isLineSelector(selector string) bool {
+	return len(selector) > 1 && selector[0] == 'L' && selector[1] >= '0' && selector[1] <= '9'
+}
+
+func selectLines(lines []string, selector string) ([]string, error) {
+	startStr, endStr, isRange := strings.Cut(selector, "-")
+
+	start, err := strconv.Atoi(strings.TrimPrefix(startStr, "L"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid line selector %q", selector)
+	}
+
+	end := start
+	if isRange {
+		end, err = strconv.Atoi(strings.TrimPrefix(endStr, "L"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid line selector %q", selector)
+		}
+	}
+
+	if start < 1 || end < start || end > len(lines) {
+		return nil, fmt.Errorf("lines %d-%d out of range (file has %d lines)", start, end, len(lines))
+	}
+
+	return lines[start-1 : end], nil
+}
+
+func selectRegion(lines []string, name string) ([]string, error) {
+	startIdx := -1
+	for i, line := range lines {
+		if startIdx < 0 {
+			if regionMarker(line, "docmd:region") == name {
+				startIdx = i + 1
+			}
+			continue
+		}
+		if strings.Contains(line, "docmd:endregion") {
+			return lines[startIdx:i], nil
+		}
+	}
+
+	if startIdx < 0 {
+		return nil, fmt.Errorf("region %q not found", name)
+	}
+	return nil, fmt.Errorf("region %q is never closed", name)
+}
+
+func regionMarker(line string, marker string) string {
+	_, rest, ok := strings.Cut(line, marker)
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	if indent <= 0 {
+		return lines
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent {
+			out[i] = line[indent:]
+		} else {
+			out[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package gdrive
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])(@([A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}))`)
+
+type mention struct {
+	tabID string
+	start int64
+	end   int64
+	email string
+}
+
+// InsertPersonChips replaces @user@example.com mentions in the document
+// with person smart chips and returns how many were converted.
+func InsertPersonChips(docID string) (int, error) {
+	srv, err := GetDocsService()
+	if err != nil {
+		return 0, err
+	}
+
+	doc, err := retry(func() (*docs.Document, error) {
+		return srv.Documents.Get(docID).IncludeTabsContent(true).Do()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read document: %w", err)
+	}
+
+	var mentions []mention
+	for _, tab := range allTabs(doc.Tabs) {
+		if tab.DocumentTab == nil || tab.DocumentTab.Body == nil {
+			continue
+		}
+		walkParagraphs(tab.DocumentTab.Body.Content, func(p *docs.Paragraph) {
+			for _, m := range findMentions(p) {
+				m.tabID = tab.TabProperties.TabId
+				mentions = append(mentions, m)
+			}
+		})
+	}
+
+	if len(mentions) == 0 {
+		return 0, nil
+	}
+
+	// Apply from the end of each tab so earlier indexes stay valid.
+	sort.Slice(mentions, func(i, j int) bool {
+		if mentions[i].tabID != mentions[j].tabID {
+			return mentions[i].tabID < mentions[j].tabID
+		}
+		return mentions[i].start > mentions[j].start
+	})
+
+	var requests []*docs.Request
+	for _, m := range mentions {
+		requests = append(requests,
+			&docs.Request{DeleteContentRange: &docs.DeleteContentRangeRequest{
+				Range: &docs.Range{StartIndex: m.start, EndIndex: m.end, TabId: m.tabID},
+			}},
+			&docs.Request{InsertPerson: &docs.InsertPersonRequest{
+				Location:         &docs.Location{Index: m.start, TabId: m.tabID},
+				PersonProperties: &docs.PersonProperties{Email: m.email},
+			}},
+		)
+	}
+
+	_, err = retryRateLimit(func() (*docs.BatchUpdateDocumentResponse, error) {
+		return srv.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{Requests: requests}).Do()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert person chips: %w", err)
+	}
+
+	return len(mentions), nil
+}
+
+// allTabs flattens the tab tree of a document.
+func allTabs(tabs []*docs.Tab) []*docs.Tab {
+	var all []*docs.Tab
+	for _, tab := range tabs {
+		all = append(all, tab)
+		all = append(all, allTabs(tab.ChildTabs)...)
+	}
+	return all
+}
+
+// walkParagraphs calls fn for every paragraph, including those in tables.
+func walkParagraphs(content []*docs.StructuralElement, fn func(*docs.Paragraph)) {
+	for _, el := range content {
+		switch {
+		case el.Paragraph != nil:
+			fn(el.Paragraph)
+		case el.Table != nil:
+			for _, row := range el.Table.TableRows {
+				for _, cell := range row.TableCells {
+					walkParagraphs(cell.Content, fn)
+				}
+			}
+		case el.TableOfContents != nil:
+			walkParagraphs(el.TableOfContents.Content, fn)
+		}
+	}
+}
+
+// findMentions locates mentions in a paragraph. Document indexes count
+// UTF-16 code units, so every byte of the paragraph text is mapped back to
+// its index.
+func findMentions(p *docs.Paragraph) []mention {
+	var text []byte
+	var index []int64
+
+	for _, el := range p.Elements {
+		if el.TextRun == nil {
+			// Non-text elements take one index and break up any mention.
+			text = append(text, ' ')
+			index = append(index, el.StartIndex)
+			continue
+		}
+		pos := el.StartIndex
+		content := el.TextRun.Content
+		for len(content) > 0 {
+			r, size := utf8.DecodeRuneInString(content)
+			for i := 0; i < size; i++ {
+				text = append(text, content[i])
+				index = append(index, pos)
+			}
+			if r >= 0x10000 {
+				pos += 2
+			} else {
+				pos++
+			}
+			content = content[size:]
+		}
+	}
+
+	var mentions []mention
+	for _, m := range mentionPattern.FindAllSubmatchIndex(text, -1) {
+		start, end := m[2], m[3]
+		mentions = append(mentions, mention{
+			start: index[start],
+			end:   index[end-1] + 1,
+			email: string(text[m[4]:m[5]]),
+		})
+	}
+
+	return mentions
+}
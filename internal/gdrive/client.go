@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"golang.org/x/oauth2"
+	"google.golang.org/api/docs/v1"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 
@@ -50,3 +51,17 @@ func GetDriveService() (*drive.Service, error) {
 
 	return srv, nil
 }
+
+func GetDocsService() (*docs.Service, error) {
+	client, err := GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := docs.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docs service: %w", err)
+	}
+
+	return srv, nil
+}
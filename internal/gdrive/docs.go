@@ -2,6 +2,8 @@ package gdrive
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 	"time"
 
@@ -15,6 +17,7 @@ type DocInfo struct {
 	Title        string
 	ModifiedTime time.Time
 	ModifiedBy   string
+	Trashed      bool
 }
 
 func CreateDoc(title string, htmlContent string, folderID string) (*DocInfo, error) {
@@ -32,10 +35,12 @@ func CreateDoc(title string, htmlContent string, folderID string) (*DocInfo, err
 		file.Parents = []string{folderID}
 	}
 
-	createdFile, err := srv.Files.Create(file).
-		Media(strings.NewReader(htmlContent), googleapi.ContentType("text/html")).
-		Fields("id, name, webViewLink, modifiedTime").
-		Do()
+	createdFile, err := retryRateLimit(func() (*drive.File, error) {
+		return srv.Files.Create(file).
+			Media(strings.NewReader(htmlContent), googleapi.ContentType("text/html")).
+			Fields("id, name, webViewLink, modifiedTime").
+			Do()
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create document: %w", err)
 	}
@@ -56,10 +61,12 @@ func UpdateDoc(docID string, htmlContent string) (*DocInfo, error) {
 		return nil, err
 	}
 
-	updatedFile, err := srv.Files.Update(docID, nil).
-		Media(strings.NewReader(htmlContent), googleapi.ContentType("text/html")).
-		Fields("id, name, webViewLink, modifiedTime").
-		Do()
+	updatedFile, err := retry(func() (*drive.File, error) {
+		return srv.Files.Update(docID, nil).
+			Media(strings.NewReader(htmlContent), googleapi.ContentType("text/html")).
+			Fields("id, name, webViewLink, modifiedTime").
+			Do()
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update document: %w", err)
 	}
@@ -80,9 +87,11 @@ func GetDocInfo(docID string) (*DocInfo, error) {
 		return nil, err
 	}
 
-	file, err := srv.Files.Get(docID).
-		Fields("id, name, webViewLink, modifiedTime, lastModifyingUser").
-		Do()
+	file, err := retry(func() (*drive.File, error) {
+		return srv.Files.Get(docID).
+			Fields("id, name, webViewLink, modifiedTime, lastModifyingUser, trashed").
+			Do()
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get document info: %w", err)
 	}
@@ -94,6 +103,7 @@ func GetDocInfo(docID string) (*DocInfo, error) {
 		URL:          file.WebViewLink,
 		Title:        file.Name,
 		ModifiedTime: modTime,
+		Trashed:      file.Trashed,
 	}
 
 	if file.LastModifyingUser != nil {
@@ -109,7 +119,10 @@ func DeleteDoc(docID string) error {
 		return err
 	}
 
-	if err := srv.Files.Delete(docID).Do(); err != nil {
+	_, err = retry(func() (any, error) {
+		return nil, srv.Files.Delete(docID).Do()
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete document: %w", err)
 	}
 
@@ -122,6 +135,99 @@ func DocExists(docID string) bool {
 		return false
 	}
 
-	_, err = srv.Files.Get(docID).Fields("id").Do()
+	_, err = retry(func() (*drive.File, error) {
+		return srv.Files.Get(docID).Fields("id").Do()
+	})
 	return err == nil
 }
+
+func CreateFolder(name string, parentID string) (*DocInfo, error) {
+	srv, err := GetDriveService()
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &drive.File{
+		Name:     name,
+		MimeType: "application/vnd.google-apps.folder",
+	}
+
+	if parentID != "" {
+		folder.Parents = []string{parentID}
+	}
+
+	created, err := retryRateLimit(func() (*drive.File, error) {
+		return srv.Files.Create(folder).
+			Fields("id, name, webViewLink, modifiedTime").
+			Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	modTime, _ := time.Parse(time.RFC3339, created.ModifiedTime)
+
+	return &DocInfo{
+		ID:           created.Id,
+		URL:          created.WebViewLink,
+		Title:        created.Name,
+		ModifiedTime: modTime,
+	}, nil
+}
+
+func RenameDoc(docID string, title string) error {
+	srv, err := GetDriveService()
+	if err != nil {
+		return err
+	}
+
+	_, err = retry(func() (*drive.File, error) {
+		return srv.Files.Update(docID, &drive.File{Name: title}).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename document: %w", err)
+	}
+
+	return nil
+}
+
+// TrashDoc moves a doc to the trash, where it can still be restored.
+func TrashDoc(docID string) error {
+	srv, err := GetDriveService()
+	if err != nil {
+		return err
+	}
+
+	_, err = retry(func() (*drive.File, error) {
+		return srv.Files.Update(docID, &drive.File{Trashed: true}).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to trash document: %w", err)
+	}
+
+	return nil
+}
+
+// ExportDoc downloads a doc converted to mimeType, such as "text/html" or
+// "text/markdown".
+func ExportDoc(docID string, mimeType string) ([]byte, error) {
+	srv, err := GetDriveService()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := retry(func() (*http.Response, error) {
+		return srv.Files.Export(docID, mimeType).Download()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export document as %s: %w", mimeType, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download export: %w", err)
+	}
+
+	return data, nil
+}
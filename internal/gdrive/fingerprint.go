@@ -0,0 +1,111 @@
+package gdrive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// appProperties keys and values share a limit of 124 bytes.
+const maxPropertyBytes = 124
+
+const (
+	propHash     = "docmd_hash"
+	propExport   = "docmd_export"
+	propSource   = "docmd_source"
+	propVersion  = "docmd_version"
+	propMachine  = "docmd_machine"
+	propPushedAt = "docmd_pushed_at"
+)
+
+// Fingerprint describes the push that last wrote a doc. It is stored in the
+// file's appProperties, which are private to docmd. Hash is the hash of the
+// rendered content and Export the hash of the doc's text export right after
+// the push.
+type Fingerprint struct {
+	Hash     string
+	Export   string
+	Source   string
+	Version  string
+	Machine  string
+	PushedAt time.Time
+}
+
+// SetFingerprint stores the fingerprint of a push on a doc. Values too long
+// for appProperties are cut from the start, a source path to its last
+// directories.
+func SetFingerprint(docID string, fp *Fingerprint) error {
+	srv, err := GetDriveService()
+	if err != nil {
+		return err
+	}
+
+	props := map[string]string{
+		propHash:     fp.Hash,
+		propExport:   fp.Export,
+		propSource:   fp.Source,
+		propVersion:  fp.Version,
+		propMachine:  fp.Machine,
+		propPushedAt: fp.PushedAt.UTC().Format(time.RFC3339),
+	}
+	for key, value := range props {
+		room := maxPropertyBytes - len(key)
+		if len(value) <= room {
+			continue
+		}
+		start := len(value) - room
+		for start < len(value) && !utf8.RuneStart(value[start]) {
+			start++
+		}
+		value = value[start:]
+		if key == propSource {
+			if _, rest, ok := strings.Cut(value, "/"); ok {
+				value = rest
+			}
+		}
+		props[key] = value
+	}
+
+	_, err = retry(func() (*drive.File, error) {
+		return srv.Files.Update(docID, &drive.File{AppProperties: props}).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store push fingerprint: %w", err)
+	}
+
+	return nil
+}
+
+// GetFingerprint returns the fingerprint stored on a doc, or nil when it has
+// none.
+func GetFingerprint(docID string) (*Fingerprint, error) {
+	srv, err := GetDriveService()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := retry(func() (*drive.File, error) {
+		return srv.Files.Get(docID).Fields("id, appProperties").Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push fingerprint: %w", err)
+	}
+
+	props := file.AppProperties
+	if props[propHash] == "" {
+		return nil, nil
+	}
+
+	pushedAt, _ := time.Parse(time.RFC3339, props[propPushedAt])
+	return &Fingerprint{
+		Hash:     props[propHash],
+		Export:   props[propExport],
+		Source:   props[propSource],
+		Version:  props[propVersion],
+		Machine:  props[propMachine],
+		PushedAt: pushedAt,
+	}, nil
+}
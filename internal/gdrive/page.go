@@ -0,0 +1,186 @@
+package gdrive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// PageSetup describes the page settings of a document. Sizes and margins
+// are in points.
+type PageSetup struct {
+	// Width and Height of the page in portrait orientation. Zero keeps the
+	// current size.
+	Width     float64
+	Height    float64
+	Landscape bool
+	Margins   *Margins
+	Pageless  bool
+}
+
+type Margins struct {
+	Top, Right, Bottom, Left float64
+}
+
+var pageSizes = map[string][2]float64{
+	"a3":      {842, 1191},
+	"a4":      {595.3, 841.9},
+	"a5":      {419.5, 595.3},
+	"letter":  {612, 792},
+	"legal":   {612, 1008},
+	"tabloid": {792, 1224},
+}
+
+var lengthUnits = map[string]float64{
+	"pt": 1,
+	"in": 72,
+	"cm": 72 / 2.54,
+	"mm": 72 / 25.4,
+}
+
+// NewPageSetup builds a page setup from user-facing values: a page size
+// name such as "a4" or "letter", "portrait" or "landscape", and margins as
+// a CSS-style shorthand of one to four lengths ("2cm", "1in 0.75in").
+// Empty values keep the document's current settings.
+func NewPageSetup(size, orientation, margins string, pageless bool) (*PageSetup, error) {
+	setup := &PageSetup{Pageless: pageless}
+
+	if size != "" {
+		dims, ok := pageSizes[strings.ToLower(size)]
+		if !ok {
+			return nil, fmt.Errorf("unknown page size %q", size)
+		}
+		setup.Width, setup.Height = dims[0], dims[1]
+	}
+
+	switch strings.ToLower(orientation) {
+	case "", "portrait":
+	case "landscape":
+		setup.Landscape = true
+	default:
+		return nil, fmt.Errorf("unknown page orientation %q", orientation)
+	}
+
+	if margins != "" {
+		m, err := parseMargins(margins)
+		if err != nil {
+			return nil, err
+		}
+		setup.Margins = m
+	}
+
+	return setup, nil
+}
+
+func parseMargins(s string) (*Margins, error) {
+	fields := strings.Fields(s)
+	values := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := parseLength(f)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	switch len(values) {
+	case 1:
+		return &Margins{values[0], values[0], values[0], values[0]}, nil
+	case 2:
+		return &Margins{values[0], values[1], values[0], values[1]}, nil
+	case 3:
+		return &Margins{values[0], values[1], values[2], values[1]}, nil
+	case 4:
+		return &Margins{values[0], values[1], values[2], values[3]}, nil
+	}
+	return nil, fmt.Errorf("invalid margins %q: expected one to four lengths", s)
+}
+
+func parseLength(s string) (float64, error) {
+	unit := "pt"
+	number := s
+	for u := range lengthUnits {
+		if strings.HasSuffix(s, u) {
+			unit = u
+			number = strings.TrimSuffix(s, u)
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid length %q: use a number with pt, in, cm or mm", s)
+	}
+	return v * lengthUnits[unit], nil
+}
+
+// ApplyPageSetup updates the page settings of a document. Re-uploading the
+// HTML resets them, so this has to run after every update.
+func ApplyPageSetup(docID string, setup *PageSetup) error {
+	srv, err := GetDocsService()
+	if err != nil {
+		return err
+	}
+
+	mode := "PAGES"
+	if setup.Pageless {
+		mode = "PAGELESS"
+	}
+
+	style := &docs.DocumentStyle{
+		DocumentFormat:      &docs.DocumentFormat{DocumentMode: mode},
+		FlipPageOrientation: setup.Landscape,
+		ForceSendFields:     []string{"FlipPageOrientation"},
+	}
+	fields := []string{"documentFormat", "flipPageOrientation"}
+
+	if setup.Width > 0 && setup.Height > 0 {
+		style.PageSize = &docs.Size{
+			Width:  points(setup.Width),
+			Height: points(setup.Height),
+		}
+		fields = append(fields, "pageSize")
+	}
+
+	if m := setup.Margins; m != nil {
+		style.MarginTop = points(m.Top)
+		style.MarginRight = points(m.Right)
+		style.MarginBottom = points(m.Bottom)
+		style.MarginLeft = points(m.Left)
+		fields = append(fields, "marginTop", "marginRight", "marginBottom", "marginLeft")
+	}
+
+	doc, err := retry(func() (*docs.Document, error) {
+		return srv.Documents.Get(docID).IncludeTabsContent(true).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read document: %w", err)
+	}
+
+	// Every tab has its own page settings.
+	var requests []*docs.Request
+	for _, tab := range allTabs(doc.Tabs) {
+		requests = append(requests, &docs.Request{
+			UpdateDocumentStyle: &docs.UpdateDocumentStyleRequest{
+				DocumentStyle: style,
+				Fields:        strings.Join(fields, ","),
+				TabId:         tab.TabProperties.TabId,
+			},
+		})
+	}
+
+	_, err = retry(func() (*docs.BatchUpdateDocumentResponse, error) {
+		return srv.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{Requests: requests}).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply page setup: %w", err)
+	}
+
+	return nil
+}
+
+func points(v float64) *docs.Dimension {
+	return &docs.Dimension{Magnitude: v, Unit: "PT", ForceSendFields: []string{"Magnitude"}}
+}
@@ -0,0 +1,138 @@
+package gdrive
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	maxAttempts = 6
+	baseBackoff = time.Second
+	maxBackoff  = 32 * time.Second
+
+	// maxRetryAfter is the longest Retry-After that is waited for; beyond
+	// it the error is returned.
+	maxRetryAfter = 2 * time.Minute
+)
+
+// rateLimitReasons are the 403 reasons Drive and Docs use for rate limits.
+var rateLimitReasons = map[string]bool{
+	"userRateLimitExceeded": true,
+	"rateLimitExceeded":     true,
+}
+
+// retry runs a request that can safely be repeated, such as a read or an
+// update that sets a value, retrying it on rate limits, server errors and
+// dropped connections.
+func retry[T any](req func() (T, error)) (T, error) {
+	return withBackoff(true, req)
+}
+
+// retryRateLimit runs a request that must not be repeated once it may have
+// been processed, such as creating a file or inserting text. It is retried
+// only when rejected by a rate limit, which means it wasn't processed.
+func retryRateLimit[T any](req func() (T, error)) (T, error) {
+	return withBackoff(false, req)
+}
+
+func withBackoff[T any](idempotent bool, req func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		result, err := req()
+		if err == nil || attempt == maxAttempts-1 {
+			return result, err
+		}
+
+		wait, ok := retryDelay(err, idempotent, attempt)
+		if !ok {
+			return result, err
+		}
+		time.Sleep(wait)
+	}
+}
+
+// retryDelay decides whether err is worth retrying and how long to wait
+// first: the server's Retry-After when given, otherwise an exponential
+// backoff with jitter.
+func retryDelay(err error, idempotent bool, attempt int) (time.Duration, bool) {
+	var gerr *googleapi.Error
+	switch {
+	case errors.As(err, &gerr):
+		if !isRateLimit(gerr) && !(idempotent && isServerError(gerr.Code)) {
+			return 0, false
+		}
+		if after, ok := retryAfter(gerr.Header); ok {
+			return after, after <= maxRetryAfter
+		}
+	case idempotent && isConnectionError(err):
+	default:
+		return 0, false
+	}
+
+	return backoff(attempt), true
+}
+
+func isRateLimit(gerr *googleapi.Error) bool {
+	if gerr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	if gerr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range gerr.Errors {
+		if rateLimitReasons[item.Reason] {
+			return true
+		}
+	}
+	return false
+}
+
+func isServerError(code int) bool {
+	switch code {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as a date.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// backoff doubles the wait with each attempt up to maxBackoff, and picks a
+// random point in its upper half so parallel clients spread out.
+func backoff(attempt int) time.Duration {
+	ceiling := maxBackoff
+	if attempt < 6 {
+		ceiling = min(baseBackoff<<attempt, maxBackoff)
+	}
+	half := ceiling / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,86 @@
+package gdrive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func apiError(code int, reason string, retryAfter string) error {
+	gerr := &googleapi.Error{Code: code, Header: http.Header{}}
+	if reason != "" {
+		gerr.Errors = []googleapi.ErrorItem{{Reason: reason}}
+	}
+	if retryAfter != "" {
+		gerr.Header.Set("Retry-After", retryAfter)
+	}
+	return fmt.Errorf("failed to update document: %w", gerr)
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		idempotent bool
+		want       bool
+	}{
+		{"too many requests", apiError(429, "", ""), false, true},
+		{"rate limit reason", apiError(403, "rateLimitExceeded", ""), false, true},
+		{"user rate limit reason", apiError(403, "userRateLimitExceeded", ""), false, true},
+		{"forbidden", apiError(403, "insufficientPermissions", ""), true, false},
+		{"not found", apiError(404, "", ""), true, false},
+		{"server error, idempotent", apiError(500, "", ""), true, true},
+		{"server error, not idempotent", apiError(503, "", ""), false, false},
+		{"connection reset, idempotent", fmt.Errorf("read: %w", syscall.ECONNRESET), true, true},
+		{"connection reset, not idempotent", fmt.Errorf("read: %w", syscall.ECONNRESET), false, false},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true, true},
+		{"other error", errors.New("invalid HTML"), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, ok := retryDelay(tt.err, tt.idempotent, 0)
+			if ok != tt.want {
+				t.Fatalf("retryDelay retries = %v, want %v", ok, tt.want)
+			}
+			if ok && (wait < baseBackoff/2 || wait > baseBackoff) {
+				t.Errorf("retryDelay wait = %v, want between %v and %v", wait, baseBackoff/2, baseBackoff)
+			}
+		})
+	}
+}
+
+func TestRetryDelayRetryAfter(t *testing.T) {
+	wait, ok := retryDelay(apiError(429, "", "7"), false, 3)
+	if !ok || wait != 7*time.Second {
+		t.Errorf("retryDelay = %v, %v, want 7s, true", wait, ok)
+	}
+
+	if _, ok := retryDelay(apiError(503, "", "3600"), true, 0); ok {
+		t.Errorf("retryDelay retries after an hour, want the error returned")
+	}
+
+	at := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+	wait, ok = retryDelay(apiError(429, "", at), false, 0)
+	if !ok || wait != 0 {
+		t.Errorf("retryDelay with a past date = %v, %v, want 0, true", wait, ok)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		ceiling := min(baseBackoff<<min(attempt, 6), maxBackoff)
+		for i := 0; i < 20; i++ {
+			wait := backoff(attempt)
+			if wait < ceiling/2 || wait > ceiling {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, wait, ceiling/2, ceiling)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package gdrive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	revisionFields = "id, modifiedTime, lastModifyingUser"
+
+	// maxRevisionPage is the largest page size revisions.list accepts.
+	maxRevisionPage = 1000
+)
+
+type Revision struct {
+	ID           string
+	ModifiedTime time.Time
+	ModifiedBy   string
+}
+
+// ListRevisions returns the revisions of a doc, oldest first. Drive merges
+// and drops old revisions of Google Docs, so the list is not complete.
+func ListRevisions(docID string) ([]*Revision, error) {
+	srv, err := GetDriveService()
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := retry(func() ([]*Revision, error) {
+		var revisions []*Revision
+		err := srv.Revisions.List(docID).
+			Fields("nextPageToken, revisions("+revisionFields+")").
+			Pages(context.Background(), func(list *drive.RevisionList) error {
+				for _, r := range list.Revisions {
+					revisions = append(revisions, newRevision(r))
+				}
+				return nil
+			})
+		return revisions, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// LatestRevision returns the current revision of a doc. Drive can't list
+// revisions newest first, so only their IDs are listed, in pages as large
+// as allowed, and the last one is fetched.
+func LatestRevision(docID string) (*Revision, error) {
+	srv, err := GetDriveService()
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := retry(func() (string, error) {
+		var latest string
+		err := srv.Revisions.List(docID).
+			PageSize(maxRevisionPage).
+			Fields("nextPageToken, revisions(id)").
+			Pages(context.Background(), func(list *drive.RevisionList) error {
+				if n := len(list.Revisions); n > 0 {
+					latest = list.Revisions[n-1].Id
+				}
+				return nil
+			})
+		return latest, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	if latest == "" {
+		return nil, fmt.Errorf("document has no revisions")
+	}
+
+	rev, err := retry(func() (*drive.Revision, error) {
+		return srv.Revisions.Get(docID, latest).Fields(revisionFields).Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision %s: %w", latest, err)
+	}
+
+	return newRevision(rev), nil
+}
+
+func newRevision(r *drive.Revision) *Revision {
+	modTime, _ := time.Parse(time.RFC3339, r.ModifiedTime)
+	rev := &Revision{
+		ID:           r.Id,
+		ModifiedTime: modTime,
+	}
+	if r.LastModifyingUser != nil {
+		rev.ModifiedBy = r.LastModifyingUser.EmailAddress
+	}
+	return rev
+}
+
+// RevisionHTML downloads a revision of a doc as HTML.
+func RevisionHTML(docID string, revisionID string) (string, error) {
+	srv, err := GetDriveService()
+	if err != nil {
+		return "", err
+	}
+
+	rev, err := retry(func() (*drive.Revision, error) {
+		return srv.Revisions.Get(docID, revisionID).Fields("id, exportLinks").Do()
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get revision %s: %w", revisionID, err)
+	}
+
+	link, ok := rev.ExportLinks["text/html"]
+	if !ok {
+		return "", fmt.Errorf("revision %s can't be exported as HTML", revisionID)
+	}
+
+	client, err := GetClient()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := retry(func() (*http.Response, error) {
+		resp, err := client.Get(link)
+		if err != nil {
+			return nil, err
+		}
+		if err := googleapi.CheckResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to download revision: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to download revision: %w", err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,404 @@
+package gdrive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// TabContent is the rendered HTML of one tab of a tabbed doc.
+type TabContent struct {
+	Title string
+	HTML  string
+}
+
+// TabResult reports the outcome of SyncTabs.
+type TabResult struct {
+	// TabIDs are the IDs of the tabs, in the order of the contents.
+	TabIDs []string
+
+	// SkippedImages counts images that could not be copied into a tab.
+	SkippedImages int
+}
+
+const emptyHTML = "<html><body></body></html>"
+
+// CreateTabbedDoc creates an empty doc whose content is filled in with
+// SyncTabs.
+func CreateTabbedDoc(title string, folderID string) (*DocInfo, error) {
+	return CreateDoc(title, emptyHTML, folderID)
+}
+
+// SyncTabs makes the doc have one tab per content, in order. Tabs listed in
+// tabIDs are reused and their content replaced; missing tabs are added and
+// tabs left over from an earlier, longer list are deleted.
+//
+// The Docs API can't import HTML into a tab, so each tab's HTML is imported
+// into a temporary doc whose content is then copied over.
+func SyncTabs(docID string, tabIDs []string, contents []TabContent) (*TabResult, error) {
+	srv, err := GetDocsService()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := retry(func() (*docs.Document, error) {
+		return srv.Documents.Get(docID).IncludeTabsContent(true).Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+
+	current := make(map[string]*docs.Tab)
+	for _, tab := range doc.Tabs {
+		current[tab.TabProperties.TabId] = tab
+	}
+
+	// A new doc has a single tab that is used for the first content.
+	if len(tabIDs) == 0 && len(doc.Tabs) == 1 {
+		tabIDs = []string{doc.Tabs[0].TabProperties.TabId}
+	}
+
+	result := &TabResult{}
+	var requests []*docs.Request
+
+	for i, content := range contents {
+		var tab *docs.Tab
+		if i < len(tabIDs) {
+			tab = current[tabIDs[i]]
+		}
+
+		if tab == nil {
+			resp, err := retryRateLimit(func() (*docs.BatchUpdateDocumentResponse, error) {
+				return srv.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
+					Requests: []*docs.Request{{
+						AddDocumentTab: &docs.AddDocumentTabRequest{
+							TabProperties: &docs.TabProperties{Title: content.Title, Index: int64(i), ForceSendFields: []string{"Index"}},
+						},
+					}},
+				}).Do()
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to add tab %q: %w", content.Title, err)
+			}
+			result.TabIDs = append(result.TabIDs, resp.Replies[0].AddDocumentTab.TabProperties.TabId)
+			continue
+		}
+
+		id := tab.TabProperties.TabId
+		result.TabIDs = append(result.TabIDs, id)
+
+		if tab.TabProperties.Title != content.Title {
+			requests = append(requests, &docs.Request{
+				UpdateDocumentTabProperties: &docs.UpdateDocumentTabPropertiesRequest{
+					TabProperties: &docs.TabProperties{TabId: id, Title: content.Title},
+					Fields:        "title",
+				},
+			})
+		}
+
+		if end := bodyEnd(tab.DocumentTab.Body); end > 2 {
+			requests = append(requests,
+				&docs.Request{DeleteContentRange: &docs.DeleteContentRangeRequest{
+					Range: &docs.Range{StartIndex: 1, EndIndex: end - 1, TabId: id},
+				}},
+				// The remaining paragraph keeps the style of the last one.
+				&docs.Request{DeleteParagraphBullets: &docs.DeleteParagraphBulletsRequest{
+					Range: &docs.Range{StartIndex: 1, EndIndex: 2, TabId: id},
+				}},
+			)
+		}
+	}
+
+	for _, id := range tabIDs[min(len(contents), len(tabIDs)):] {
+		if current[id] != nil {
+			requests = append(requests, &docs.Request{DeleteTab: &docs.DeleteTabRequest{TabId: id}})
+		}
+	}
+
+	if len(requests) > 0 {
+		_, err := retryRateLimit(func() (*docs.BatchUpdateDocumentResponse, error) {
+			return srv.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{Requests: requests}).Do()
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare tabs: %w", err)
+		}
+	}
+
+	for i, content := range contents {
+		skipped, err := fillTab(srv, docID, result.TabIDs[i], content)
+		if err != nil {
+			return nil, err
+		}
+		result.SkippedImages += skipped
+	}
+
+	return result, nil
+}
+
+func bodyEnd(body *docs.Body) int64 {
+	if body == nil || len(body.Content) == 0 {
+		return 1
+	}
+	return body.Content[len(body.Content)-1].EndIndex
+}
+
+// fillTab copies the content of the tab's HTML into the empty tab and
+// returns how many images could not be copied.
+func fillTab(srv *docs.Service, docID string, tabID string, content TabContent) (int, error) {
+	tmp, err := CreateDoc("docmd tab: "+content.Title, content.HTML, "")
+	if err != nil {
+		return 0, err
+	}
+	defer DeleteDoc(tmp.ID)
+
+	source, err := retry(func() (*docs.Document, error) {
+		return srv.Documents.Get(tmp.ID).Do()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read imported tab %q: %w", content.Title, err)
+	}
+
+	c := &tabCopier{
+		tabID:   tabID,
+		lists:   source.Lists,
+		objects: source.InlineObjects,
+	}
+	c.write(source.Body.Content, 1, true)
+
+	if len(c.requests) > 0 {
+		_, err = retryRateLimit(func() (*docs.BatchUpdateDocumentResponse, error) {
+			return srv.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{Requests: c.requests}).Do()
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to fill tab %q: %w", content.Title, err)
+		}
+	}
+
+	// Insert images last, from the end, so earlier indexes stay valid.
+	// They are fetched by URL, which can fail, so one bad image doesn't
+	// fail the tab.
+	sort.Slice(c.images, func(i, j int) bool { return c.images[i].index > c.images[j].index })
+	skipped := 0
+	for _, img := range c.images {
+		_, err := retryRateLimit(func() (*docs.BatchUpdateDocumentResponse, error) {
+			return srv.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
+				Requests: []*docs.Request{{InsertInlineImage: img.request}},
+			}).Do()
+		})
+		if err != nil {
+			skipped++
+		}
+	}
+
+	return skipped, nil
+}
+
+type pendingImage struct {
+	index   int64
+	request *docs.InsertInlineImageRequest
+}
+
+// tabCopier builds the requests that recreate a document body in a tab.
+// Content is only ever appended, so every index is computed up front.
+type tabCopier struct {
+	tabID    string
+	lists    map[string]docs.List
+	objects  map[string]docs.InlineObject
+	requests []*docs.Request
+	images   []pendingImage
+}
+
+const paragraphStyleFields = "namedStyleType,alignment,lineSpacing,spaceAbove,spaceBelow," +
+	"indentStart,indentEnd,indentFirstLine,shading,keepLinesTogether,keepWithNext"
+
+// write copies content to index and returns the index after it. With
+// trimLast the final newline is left out, so the last paragraph takes the
+// place of the empty paragraph already at index.
+func (c *tabCopier) write(content []*docs.StructuralElement, index int64, trimLast bool) int64 {
+	for i, el := range content {
+		switch {
+		case el.Paragraph != nil:
+			index = c.paragraph(el.Paragraph, index, trimLast && i == len(content)-1)
+		case el.Table != nil:
+			index = c.table(el.Table, index)
+		}
+	}
+	return index
+}
+
+func (c *tabCopier) paragraph(p *docs.Paragraph, index int64, trim bool) int64 {
+	type run struct {
+		start, end int64
+		style      *docs.TextStyle
+	}
+
+	var (
+		text  strings.Builder
+		runs  []run
+		pos   = index
+		level int64
+	)
+
+	// Paragraphs become list items through CreateParagraphBullets, which
+	// takes the nesting level from leading tabs and then removes them.
+	if p.Bullet != nil {
+		level = p.Bullet.NestingLevel
+		text.WriteString(strings.Repeat("\t", int(level)))
+		pos += level
+	}
+
+	for _, el := range p.Elements {
+		switch {
+		case el.TextRun != nil:
+			n := utf16Len(el.TextRun.Content)
+			runs = append(runs, run{pos, pos + n, el.TextRun.TextStyle})
+			text.WriteString(el.TextRun.Content)
+			pos += n
+		case el.Person != nil && el.Person.PersonProperties != nil:
+			s := "@" + el.Person.PersonProperties.Email
+			n := utf16Len(s)
+			runs = append(runs, run{pos, pos + n, el.Person.TextStyle})
+			text.WriteString(s)
+			pos += n
+		case el.InlineObjectElement != nil:
+			c.image(el.InlineObjectElement.InlineObjectId, pos-level)
+		}
+	}
+
+	s := text.String()
+	paragraphEnd := pos
+	if trim && strings.HasSuffix(s, "\n") {
+		s = strings.TrimSuffix(s, "\n")
+		pos--
+		if n := len(runs); n > 0 && runs[n-1].end > pos {
+			runs[n-1].end = pos
+		}
+	}
+
+	if s != "" {
+		c.add(&docs.Request{InsertText: &docs.InsertTextRequest{
+			Text:     s,
+			Location: &docs.Location{Index: index, TabId: c.tabID},
+		}})
+	}
+
+	for _, r := range runs {
+		if r.end <= r.start {
+			continue
+		}
+		style := r.style
+		if style == nil {
+			style = &docs.TextStyle{}
+		}
+		c.add(&docs.Request{UpdateTextStyle: &docs.UpdateTextStyleRequest{
+			TextStyle: style,
+			Fields:    "*",
+			Range:     c.rangeOf(r.start, r.end),
+		}})
+	}
+
+	if p.ParagraphStyle != nil {
+		c.add(&docs.Request{UpdateParagraphStyle: &docs.UpdateParagraphStyleRequest{
+			ParagraphStyle: p.ParagraphStyle,
+			Fields:         paragraphStyleFields,
+			Range:          c.rangeOf(index, paragraphEnd),
+		}})
+	}
+
+	if p.Bullet != nil {
+		c.add(&docs.Request{CreateParagraphBullets: &docs.CreateParagraphBulletsRequest{
+			BulletPreset: c.bulletPreset(p.Bullet),
+			Range:        c.rangeOf(index, paragraphEnd),
+		}})
+	}
+
+	return pos - level
+}
+
+func (c *tabCopier) bulletPreset(b *docs.Bullet) string {
+	if list, ok := c.lists[b.ListId]; ok && list.ListProperties != nil {
+		levels := list.ListProperties.NestingLevels
+		if int(b.NestingLevel) < len(levels) {
+			switch levels[b.NestingLevel].GlyphType {
+			case "", "GLYPH_TYPE_UNSPECIFIED", "NONE":
+			default:
+				return "NUMBERED_DECIMAL_ALPHA_ROMAN"
+			}
+		}
+	}
+	return "BULLET_DISC_CIRCLE_SQUARE"
+}
+
+// table inserts an empty table and fills its cells. InsertTable adds a
+// newline before the table, and every row, cell and empty cell paragraph
+// takes one index.
+func (c *tabCopier) table(t *docs.Table, index int64) int64 {
+	c.add(&docs.Request{InsertTable: &docs.InsertTableRequest{
+		Rows:     t.Rows,
+		Columns:  t.Columns,
+		Location: &docs.Location{Index: index, TabId: c.tabID},
+	}})
+
+	start := index + 1
+	pos := start + 1
+	for r, row := range t.TableRows {
+		pos++
+		for col, cell := range row.TableCells {
+			pos++
+			pos = c.write(cell.Content, pos, true) + 1
+
+			if style := cell.TableCellStyle; style != nil && style.BackgroundColor != nil {
+				c.add(&docs.Request{UpdateTableCellStyle: &docs.UpdateTableCellStyleRequest{
+					TableCellStyle: &docs.TableCellStyle{BackgroundColor: style.BackgroundColor},
+					Fields:         "backgroundColor",
+					TableRange: &docs.TableRange{
+						TableCellLocation: &docs.TableCellLocation{
+							TableStartLocation: &docs.Location{Index: start, TabId: c.tabID},
+							RowIndex:           int64(r),
+							ColumnIndex:        int64(col),
+						},
+						RowSpan:    1,
+						ColumnSpan: 1,
+					},
+				}})
+			}
+		}
+	}
+
+	return pos
+}
+
+func (c *tabCopier) image(objectID string, index int64) {
+	obj, ok := c.objects[objectID]
+	if !ok || obj.InlineObjectProperties == nil || obj.InlineObjectProperties.EmbeddedObject == nil {
+		return
+	}
+	embedded := obj.InlineObjectProperties.EmbeddedObject
+	if embedded.ImageProperties == nil || embedded.ImageProperties.ContentUri == "" {
+		return
+	}
+
+	c.images = append(c.images, pendingImage{
+		index: index,
+		request: &docs.InsertInlineImageRequest{
+			Uri:        embedded.ImageProperties.ContentUri,
+			ObjectSize: embedded.Size,
+			Location:   &docs.Location{Index: index, TabId: c.tabID},
+		},
+	})
+}
+
+func (c *tabCopier) add(r *docs.Request) {
+	c.requests = append(c.requests, r)
+}
+
+func (c *tabCopier) rangeOf(start, end int64) *docs.Range {
+	return &docs.Range{StartIndex: start, EndIndex: end, TabId: c.tabID}
+}
+
+func utf16Len(s string) int64 {
+	return int64(len(utf16.Encode([]rune(s))))
+}
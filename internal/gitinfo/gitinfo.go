@@ -0,0 +1,72 @@
+package gitinfo
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type Info struct {
+	Root   string
+	Repo   string
+	Path   string
+	Commit string
+	Branch string
+}
+
+// Lookup returns what git knows about filePath. Fields are left empty
+// when the file is not inside a repository or git is not installed.
+func Lookup(filePath string) Info {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+
+	info := Info{Path: filepath.Base(absPath)}
+	dir := filepath.Dir(absPath)
+
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil || root == "" {
+		return info
+	}
+	info.Root = root
+
+	if rel, err := filepath.Rel(root, absPath); err == nil {
+		info.Path = filepath.ToSlash(rel)
+	}
+
+	if remote, err := git(dir, "config", "--get", "remote.origin.url"); err == nil && remote != "" {
+		info.Repo = cleanRemote(remote)
+	} else {
+		info.Repo = filepath.Base(root)
+	}
+
+	info.Commit, _ = git(dir, "rev-parse", "--short", "HEAD")
+	info.Branch, _ = git(dir, "rev-parse", "--abbrev-ref", "HEAD")
+
+	return info
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func cleanRemote(remote string) string {
+	remote = strings.TrimSuffix(remote, ".git")
+	if strings.HasPrefix(remote, "git@") {
+		remote = strings.TrimPrefix(remote, "git@")
+		remote = strings.Replace(remote, ":", "/", 1)
+	}
+	remote = strings.TrimPrefix(remote, "https://")
+	remote = strings.TrimPrefix(remote, "http://")
+	remote = strings.TrimPrefix(remote, "ssh://")
+	if at := strings.Index(remote, "@"); at >= 0 && at < strings.Index(remote, "/") {
+		remote = remote[at+1:]
+	}
+	return remote
+}
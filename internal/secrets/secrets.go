@@ -0,0 +1,190 @@
+package secrets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Rule is a named pattern for a kind of credential.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Finding is a match of a rule.
+type Finding struct {
+	Rule  string
+	Match string
+}
+
+// DefaultRules cover common credential formats. High-entropy strings are
+// detected separately.
+var DefaultRules = []Rule{
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|private).{0,20}?[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{50,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Stripe key", regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{20,}\b`)},
+	{"JSON web token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"credential assignment", regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|token|passw(?:or)?d)["']?\s*[:=]\s*["']?[A-Za-z0-9_\-/+=.]{16,}`)},
+}
+
+var (
+	htmlHidden   = regexp.MustCompile(`(?is)<(?:style|script)\b.*?</(?:style|script)>`)
+	htmlTag      = regexp.MustCompile(`<[^>]*>`)
+	linkAttr     = regexp.MustCompile(`(?i)\s(?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	entropyToken = regexp.MustCompile(`[A-Za-z0-9+=_-]{32,}`)
+	// googleFileID matches the IDs in Google Docs and Drive URLs, which
+	// docmd writes for links between linked files.
+	googleFileID = regexp.MustCompile(`(?:docs|drive)\.google\.com/[^\s"'<>]*?(?:/d/|/folders/|[?&]id=)([A-Za-z0-9_-]+)`)
+	hexOnly      = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+)
+
+const (
+	minTokenBits  = 4.5
+	maxMatchShown = 12
+)
+
+// CompileRules compiles named custom patterns.
+func CompileRules(patterns map[string]string) ([]Rule, error) {
+	var rules []Rule
+	for name, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %q: %w", name, err)
+		}
+		rules = append(rules, Rule{Name: name, Pattern: re})
+	}
+	return rules, nil
+}
+
+// ScanHTML scans the text of an HTML document along with its link and image
+// targets. Styles, scripts and data URIs are left out, as embedded images
+// would read as random strings. Matches listed in allow, verbatim or by
+// fingerprint, are skipped.
+func ScanHTML(doc string, rules []Rule, allow []string) []Finding {
+	doc = htmlHidden.ReplaceAllString(doc, " ")
+	doc = htmlTag.ReplaceAllStringFunc(doc, func(tag string) string {
+		targets := []string{""}
+		for _, m := range linkAttr.FindAllStringSubmatch(tag, -1) {
+			value := m[1] + m[2]
+			if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), "data:") {
+				targets = append(targets, value)
+			}
+		}
+		return strings.Join(append(targets, ""), " ")
+	})
+	return Scan(html.UnescapeString(doc), rules, allow)
+}
+
+// Scan reports matches of rules and high-entropy strings in text. Where
+// several rules match the same text only the first is reported.
+func Scan(text string, rules []Rule, allow []string) []Finding {
+	skip := make(map[string]bool, len(allow))
+	for _, a := range allow {
+		skip[a] = true
+	}
+
+	var findings []Finding
+	var covered [][]int
+	seen := make(map[string]bool)
+	add := func(rule string, loc []int) {
+		if overlaps(loc, covered) {
+			return
+		}
+		covered = append(covered, loc)
+
+		match := text[loc[0]:loc[1]]
+		if skip[match] || skip[Fingerprint(match)] || seen[match] {
+			return
+		}
+		seen[match] = true
+		findings = append(findings, Finding{Rule: rule, Match: match})
+	}
+
+	for _, r := range rules {
+		for _, loc := range r.Pattern.FindAllStringIndex(text, -1) {
+			add(r.Name, loc)
+		}
+	}
+
+	var fileIDs [][]int
+	for _, m := range googleFileID.FindAllStringSubmatchIndex(text, -1) {
+		fileIDs = append(fileIDs, m[2:4])
+	}
+	for _, loc := range entropyToken.FindAllStringIndex(text, -1) {
+		if !overlaps(loc, fileIDs) && looksRandom(text[loc[0]:loc[1]]) {
+			add("high-entropy string", loc)
+		}
+	}
+
+	return findings
+}
+
+func overlaps(loc []int, spans [][]int) bool {
+	for _, s := range spans {
+		if loc[0] < s[1] && s[0] < loc[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// looksRandom reports whether s mixes letters and digits with the entropy
+// of generated key material. Hex strings such as commit hashes are left
+// alone.
+func looksRandom(s string) bool {
+	if hexOnly.MatchString(s) {
+		return false
+	}
+	var upper, lower, digit bool
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && entropy(s) >= minTokenBits
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+	n := float64(len(s))
+	bits := 0.0
+	for _, count := range counts {
+		p := float64(count) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// Fingerprint identifies a match without revealing it, so that it can be
+// allowed in the config.
+func Fingerprint(match string) string {
+	sum := sha256.Sum256([]byte(match))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Masked shortens a match for display, keeping its start.
+func (f Finding) Masked() string {
+	runes := []rune(f.Match)
+	if len(runes) <= maxMatchShown {
+		return f.Match
+	}
+	return string(runes[:maxMatchShown]) + strings.Repeat("*", 4)
+}
@@ -0,0 +1,78 @@
+package store
+
+import "github.com/ohhmaar/docmd/internal/gdrive"
+
+// Drive stores docs as Google Docs.
+type Drive struct{}
+
+func (Drive) CreateDoc(title string, htmlContent string, folderID string) (*gdrive.DocInfo, error) {
+	return gdrive.CreateDoc(title, htmlContent, folderID)
+}
+
+func (Drive) UpdateDoc(docID string, htmlContent string) (*gdrive.DocInfo, error) {
+	return gdrive.UpdateDoc(docID, htmlContent)
+}
+
+func (Drive) GetDocInfo(docID string) (*gdrive.DocInfo, error) {
+	return gdrive.GetDocInfo(docID)
+}
+
+func (Drive) DocExists(docID string) bool {
+	return gdrive.DocExists(docID)
+}
+
+func (Drive) DeleteDoc(docID string) error {
+	return gdrive.DeleteDoc(docID)
+}
+
+func (Drive) TrashDoc(docID string) error {
+	return gdrive.TrashDoc(docID)
+}
+
+func (Drive) RenameDoc(docID string, title string) error {
+	return gdrive.RenameDoc(docID, title)
+}
+
+func (Drive) CreateFolder(name string, parentID string) (*gdrive.DocInfo, error) {
+	return gdrive.CreateFolder(name, parentID)
+}
+
+func (Drive) ExportDoc(docID string, mimeType string) ([]byte, error) {
+	return gdrive.ExportDoc(docID, mimeType)
+}
+
+func (Drive) CreateTabbedDoc(title string, folderID string) (*gdrive.DocInfo, error) {
+	return gdrive.CreateTabbedDoc(title, folderID)
+}
+
+func (Drive) SyncTabs(docID string, tabIDs []string, contents []gdrive.TabContent) (*gdrive.TabResult, error) {
+	return gdrive.SyncTabs(docID, tabIDs, contents)
+}
+
+func (Drive) InsertPersonChips(docID string) (int, error) {
+	return gdrive.InsertPersonChips(docID)
+}
+
+func (Drive) ApplyPageSetup(docID string, setup *gdrive.PageSetup) error {
+	return gdrive.ApplyPageSetup(docID, setup)
+}
+
+func (Drive) ListRevisions(docID string) ([]*gdrive.Revision, error) {
+	return gdrive.ListRevisions(docID)
+}
+
+func (Drive) LatestRevision(docID string) (*gdrive.Revision, error) {
+	return gdrive.LatestRevision(docID)
+}
+
+func (Drive) RevisionHTML(docID string, revisionID string) (string, error) {
+	return gdrive.RevisionHTML(docID, revisionID)
+}
+
+func (Drive) SetFingerprint(docID string, fp *gdrive.Fingerprint) error {
+	return gdrive.SetFingerprint(docID, fp)
+}
+
+func (Drive) GetFingerprint(docID string) (*gdrive.Fingerprint, error) {
+	return gdrive.GetFingerprint(docID)
+}
@@ -0,0 +1,436 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/ohhmaar/docmd/internal/gdrive"
+)
+
+var spaceRun = regexp.MustCompile(`\s+`)
+
+const (
+	metaFileName = "doc.json"
+	htmlFileName = "doc.html"
+	revisionsDir = "revisions"
+	tabsDir      = "tabs"
+)
+
+// FS stores docs in a local directory, one subdirectory per doc holding the
+// rendered HTML, its revisions and a doc.json with the metadata Drive would
+// keep. It needs no Google account, for offline dry runs, demos and tests.
+type FS struct {
+	dir string
+}
+
+type fsTab struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type fsDoc struct {
+	ID          string              `json:"id"`
+	Title       string              `json:"title"`
+	Parent      string              `json:"parent,omitempty"`
+	Folder      bool                `json:"folder,omitempty"`
+	Modified    time.Time           `json:"modified"`
+	ModifiedBy  string              `json:"modified_by,omitempty"`
+	Trashed     bool                `json:"trashed,omitempty"`
+	Tabs        []fsTab             `json:"tabs,omitempty"`
+	NextTab     int                 `json:"next_tab,omitempty"`
+	Page        *gdrive.PageSetup   `json:"page,omitempty"`
+	Revisions   []*gdrive.Revision  `json:"revisions,omitempty"`
+	Fingerprint *gdrive.Fingerprint `json:"fingerprint,omitempty"`
+}
+
+// NewFS returns a store writing to dir, creating it when needed.
+func NewFS(dir string) (*FS, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return &FS{dir: dir}, nil
+}
+
+func (s *FS) docDir(docID string) string {
+	return filepath.Join(s.dir, filepath.Base(docID))
+}
+
+func (s *FS) load(docID string) (*fsDoc, error) {
+	data, err := os.ReadFile(filepath.Join(s.docDir(docID), metaFileName))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("document %s not found", docID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc fsDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid metadata for document %s: %w", docID, err)
+	}
+	return &doc, nil
+}
+
+func (s *FS) save(doc *fsDoc) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.docDir(doc.ID), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.docDir(doc.ID), metaFileName), data, 0600)
+}
+
+func (s *FS) info(doc *fsDoc) *gdrive.DocInfo {
+	url := "file://" + s.docDir(doc.ID)
+	if !doc.Folder {
+		url += "/" + htmlFileName
+	}
+	return &gdrive.DocInfo{
+		ID:           doc.ID,
+		URL:          url,
+		Title:        doc.Title,
+		ModifiedTime: doc.Modified,
+		ModifiedBy:   doc.ModifiedBy,
+		Trashed:      doc.Trashed,
+	}
+}
+
+// write replaces the content of a doc and records it as a new revision.
+func (s *FS) write(doc *fsDoc, htmlContent string) error {
+	dir := s.docDir(doc.ID)
+	if err := os.MkdirAll(filepath.Join(dir, revisionsDir), 0700); err != nil {
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+
+	doc.Modified = time.Now()
+	doc.ModifiedBy = currentUser()
+	rev := &gdrive.Revision{
+		ID:           strconv.Itoa(len(doc.Revisions) + 1),
+		ModifiedTime: doc.Modified,
+		ModifiedBy:   doc.ModifiedBy,
+	}
+	doc.Revisions = append(doc.Revisions, rev)
+
+	for _, path := range []string{
+		filepath.Join(dir, htmlFileName),
+		filepath.Join(dir, revisionsDir, rev.ID+".html"),
+	} {
+		if err := os.WriteFile(path, []byte(htmlContent), 0600); err != nil {
+			return fmt.Errorf("failed to write document: %w", err)
+		}
+	}
+
+	return s.save(doc)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "local-" + hex.EncodeToString(b), nil
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+func (s *FS) CreateDoc(title string, htmlContent string, folderID string) (*gdrive.DocInfo, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create document: %w", err)
+	}
+
+	doc := &fsDoc{ID: id, Title: title, Parent: folderID}
+	if err := s.write(doc, htmlContent); err != nil {
+		return nil, err
+	}
+	return s.info(doc), nil
+}
+
+func (s *FS) UpdateDoc(docID string, htmlContent string) (*gdrive.DocInfo, error) {
+	doc, err := s.load(docID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.write(doc, htmlContent); err != nil {
+		return nil, err
+	}
+	return s.info(doc), nil
+}
+
+func (s *FS) GetDocInfo(docID string) (*gdrive.DocInfo, error) {
+	doc, err := s.load(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document info: %w", err)
+	}
+	return s.info(doc), nil
+}
+
+func (s *FS) DocExists(docID string) bool {
+	_, err := s.load(docID)
+	return err == nil
+}
+
+// DeleteDoc deletes a doc, or a folder with everything in it.
+func (s *FS) DeleteDoc(docID string) error {
+	if _, err := s.load(docID); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	children, err := s.children(docID)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	for _, child := range children {
+		if err := s.DeleteDoc(child.ID); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(s.docDir(docID))
+}
+
+// TrashDoc moves a doc, or a folder with everything in it, to the trash.
+func (s *FS) TrashDoc(docID string) error {
+	doc, err := s.load(docID)
+	if err != nil {
+		return fmt.Errorf("failed to trash document: %w", err)
+	}
+	children, err := s.children(docID)
+	if err != nil {
+		return fmt.Errorf("failed to trash document: %w", err)
+	}
+	for _, child := range children {
+		if err := s.TrashDoc(child.ID); err != nil {
+			return err
+		}
+	}
+	doc.Trashed = true
+	return s.save(doc)
+}
+
+// children returns the docs and folders in a folder.
+func (s *FS) children(folderID string) ([]*fsDoc, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var children []*fsDoc
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		doc, err := s.load(e.Name())
+		if err != nil {
+			continue
+		}
+		if doc.Parent == folderID {
+			children = append(children, doc)
+		}
+	}
+	return children, nil
+}
+
+func (s *FS) RenameDoc(docID string, title string) error {
+	doc, err := s.load(docID)
+	if err != nil {
+		return fmt.Errorf("failed to rename document: %w", err)
+	}
+	doc.Title = title
+	return s.save(doc)
+}
+
+func (s *FS) CreateFolder(name string, parentID string) (*gdrive.DocInfo, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	doc := &fsDoc{ID: id, Title: name, Parent: parentID, Folder: true, Modified: time.Now()}
+	if err := s.save(doc); err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+	return s.info(doc), nil
+}
+
+// ExportDoc supports HTML and plain text.
+func (s *FS) ExportDoc(docID string, mimeType string) ([]byte, error) {
+	if _, err := s.load(docID); err != nil {
+		return nil, fmt.Errorf("failed to export document: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.docDir(docID), htmlFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to export document: %w", err)
+	}
+
+	switch mimeType {
+	case "text/html":
+		return data, nil
+	case "text/plain":
+		return []byte(plainText(string(data))), nil
+	default:
+		return nil, fmt.Errorf("the local store can't export %s", mimeType)
+	}
+}
+
+func (s *FS) CreateTabbedDoc(title string, folderID string) (*gdrive.DocInfo, error) {
+	return s.CreateDoc(title, "", folderID)
+}
+
+// SyncTabs writes each tab to tabs/<id>.html and their concatenation to
+// the doc.
+func (s *FS) SyncTabs(docID string, tabIDs []string, contents []gdrive.TabContent) (*gdrive.TabResult, error) {
+	doc, err := s.load(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(s.docDir(docID), tabsDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to update tabs: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to update tabs: %w", err)
+	}
+
+	result := &gdrive.TabResult{}
+	doc.Tabs = nil
+	var combined strings.Builder
+	for i, content := range contents {
+		id := ""
+		if i < len(tabIDs) {
+			id = tabIDs[i]
+		} else {
+			doc.NextTab++
+			id = "t." + strconv.Itoa(doc.NextTab)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, id+".html"), []byte(content.HTML), 0600); err != nil {
+			return nil, fmt.Errorf("failed to update tabs: %w", err)
+		}
+		doc.Tabs = append(doc.Tabs, fsTab{ID: id, Title: content.Title})
+		result.TabIDs = append(result.TabIDs, id)
+		combined.WriteString(content.HTML + "\n")
+	}
+
+	if err := s.write(doc, combined.String()); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// InsertPersonChips does nothing: chips only exist in Google Docs.
+func (s *FS) InsertPersonChips(docID string) (int, error) {
+	return 0, nil
+}
+
+// ApplyPageSetup records the page setup in the metadata.
+func (s *FS) ApplyPageSetup(docID string, setup *gdrive.PageSetup) error {
+	doc, err := s.load(docID)
+	if err != nil {
+		return err
+	}
+	doc.Page = setup
+	return s.save(doc)
+}
+
+func (s *FS) ListRevisions(docID string) ([]*gdrive.Revision, error) {
+	doc, err := s.load(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	return doc.Revisions, nil
+}
+
+func (s *FS) LatestRevision(docID string) (*gdrive.Revision, error) {
+	revisions, err := s.ListRevisions(docID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("document has no revisions")
+	}
+	return revisions[len(revisions)-1], nil
+}
+
+func (s *FS) RevisionHTML(docID string, revisionID string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.docDir(docID), revisionsDir, filepath.Base(revisionID)+".html"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get revision %s: %w", revisionID, err)
+	}
+	return string(data), nil
+}
+
+func (s *FS) SetFingerprint(docID string, fp *gdrive.Fingerprint) error {
+	doc, err := s.load(docID)
+	if err != nil {
+		return fmt.Errorf("failed to store push fingerprint: %w", err)
+	}
+	doc.Fingerprint = fp
+	return s.save(doc)
+}
+
+func (s *FS) GetFingerprint(docID string) (*gdrive.Fingerprint, error) {
+	doc, err := s.load(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push fingerprint: %w", err)
+	}
+	return doc.Fingerprint, nil
+}
+
+// plainText extracts the text of an HTML document, a line per block.
+func plainText(doc string) string {
+	var sb strings.Builder
+	z := html.NewTokenizer(strings.NewReader(doc))
+	skip := 0
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			lines := strings.Split(sb.String(), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSpace(line)
+			}
+			return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+		case html.TextToken:
+			if skip == 0 {
+				sb.WriteString(spaceRun.ReplaceAllString(string(z.Text()), " "))
+			}
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "style", "script", "head":
+				if tt == html.StartTagToken {
+					skip++
+				} else if tt == html.EndTagToken && skip > 0 {
+					skip--
+				}
+			case "p", "div", "li", "tr", "br", "h1", "h2", "h3", "h4", "h5", "h6", "pre", "blockquote":
+				sb.WriteString("\n")
+			case "td", "th":
+				sb.WriteString("\t")
+			}
+		}
+	}
+}
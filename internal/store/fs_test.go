@@ -0,0 +1,82 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/ohhmaar/docmd/internal/gdrive"
+)
+
+// newFolderTree creates a folder holding a doc and a subfolder with another
+// doc, plus a doc outside the folder.
+func newFolderTree(t *testing.T) (s *FS, folder, doc, sub, subDoc, outside string) {
+	t.Helper()
+
+	s, err := NewFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(title, parent string, isFolder bool) string {
+		var info *gdrive.DocInfo
+		var err error
+		if isFolder {
+			info, err = s.CreateFolder(title, parent)
+		} else {
+			info, err = s.CreateDoc(title, "<p>"+title+"</p>", parent)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.ID
+	}
+
+	folder = create("Guide", "", true)
+	doc = create("Guide 1", folder, false)
+	sub = create("Parts", folder, true)
+	subDoc = create("Guide 2", sub, false)
+	outside = create("Other", "", false)
+	return s, folder, doc, sub, subDoc, outside
+}
+
+func TestFSTrashFolder(t *testing.T) {
+	s, folder, doc, sub, subDoc, outside := newFolderTree(t)
+
+	if err := s.TrashDoc(folder); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{folder, doc, sub, subDoc} {
+		info, err := s.GetDocInfo(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.Trashed {
+			t.Errorf("%s not trashed with its folder", info.Title)
+		}
+	}
+
+	info, err := s.GetDocInfo(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Trashed {
+		t.Error("doc outside the folder was trashed")
+	}
+}
+
+func TestFSDeleteFolder(t *testing.T) {
+	s, folder, doc, sub, subDoc, outside := newFolderTree(t)
+
+	if err := s.DeleteDoc(folder); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{folder, doc, sub, subDoc} {
+		if s.DocExists(id) {
+			t.Errorf("%s still exists after deleting its folder", id)
+		}
+	}
+	if !s.DocExists(outside) {
+		t.Error("doc outside the folder was deleted")
+	}
+}
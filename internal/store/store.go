@@ -0,0 +1,62 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ohhmaar/docmd/internal/config"
+	"github.com/ohhmaar/docmd/internal/gdrive"
+)
+
+const defaultDirName = "store"
+
+// Store keeps the docs that linked files are pushed to. Drive is the
+// default; FS writes them to a local directory instead.
+type Store interface {
+	CreateDoc(title string, htmlContent string, folderID string) (*gdrive.DocInfo, error)
+	UpdateDoc(docID string, htmlContent string) (*gdrive.DocInfo, error)
+	GetDocInfo(docID string) (*gdrive.DocInfo, error)
+	DocExists(docID string) bool
+	DeleteDoc(docID string) error
+	TrashDoc(docID string) error
+	RenameDoc(docID string, title string) error
+	CreateFolder(name string, parentID string) (*gdrive.DocInfo, error)
+	ExportDoc(docID string, mimeType string) ([]byte, error)
+
+	CreateTabbedDoc(title string, folderID string) (*gdrive.DocInfo, error)
+	SyncTabs(docID string, tabIDs []string, contents []gdrive.TabContent) (*gdrive.TabResult, error)
+
+	InsertPersonChips(docID string) (int, error)
+	ApplyPageSetup(docID string, setup *gdrive.PageSetup) error
+
+	ListRevisions(docID string) ([]*gdrive.Revision, error)
+	LatestRevision(docID string) (*gdrive.Revision, error)
+	RevisionHTML(docID string, revisionID string) (string, error)
+
+	SetFingerprint(docID string, fp *gdrive.Fingerprint) error
+	GetFingerprint(docID string) (*gdrive.Fingerprint, error)
+}
+
+// Open returns the store selected by the config.
+func Open(sc *config.StoreConfig) (Store, error) {
+	if sc == nil {
+		return Drive{}, nil
+	}
+
+	switch sc.Type {
+	case "", "drive":
+		return Drive{}, nil
+	case "local":
+		dir := sc.Dir
+		if dir == "" {
+			configDir, err := config.GetConfigDir()
+			if err != nil {
+				return nil, err
+			}
+			dir = filepath.Join(configDir, defaultDirName)
+		}
+		return NewFS(dir)
+	default:
+		return nil, fmt.Errorf("unknown store type %q (use \"drive\" or \"local\")", sc.Type)
+	}
+}
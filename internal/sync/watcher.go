@@ -3,6 +3,7 @@ package sync
 import (
 	"fmt"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -11,81 +12,59 @@ import (
 type WatchConfig struct {
 	DebounceMs int
 	OnChange   func(filePath string) error
+	// Dependencies, if set, returns additional files whose changes should
+	// trigger OnChange for filePath. It is called again after every change
+	// so that newly referenced files are picked up.
+	Dependencies func(filePath string) []string
 }
 
 func WatchFile(filePath string, config WatchConfig) error {
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
-	}
+	return WatchFiles([]string{filePath}, config)
+}
 
+func WatchFiles(filePaths []string, config WatchConfig) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return fmt.Errorf("failed to create watcher: %w", err)
 	}
 	defer watcher.Close()
 
-	debounceDuration := time.Duration(config.DebounceMs) * time.Millisecond
-	var debounceTimer *time.Timer
-
-	errChan := make(chan error, 1)
-
-	go func() {
-		for {
-			select {
-			case event, ok := <-watcher.Events:
-				if !ok {
-					return
-				}
-
-				eventPath, _ := filepath.Abs(event.Name)
-
-				if eventPath == absPath {
-					if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Chmod) {
-						if debounceTimer != nil {
-							debounceTimer.Stop()
-						}
-						debounceTimer = time.AfterFunc(debounceDuration, func() {
-							if err := config.OnChange(absPath); err != nil {
-								timestamp := time.Now().Format("15:04:05")
-								fmt.Printf("[%s] Error: %v\n", timestamp, err)
-							}
-						})
-					}
-				}
+	var mu sync.Mutex
+	watchedFiles := make(map[string]bool)
+	watchedDirs := make(map[string]bool)
+	owners := make(map[string]map[string]bool)
 
-			case err, ok := <-watcher.Errors:
-				if !ok {
-					return
-				}
-				errChan <- err
-				return
+	watch := func(path string) {
+		if !watchedDirs[filepath.Dir(path)] {
+			if err := watcher.Add(filepath.Dir(path)); err == nil {
+				watchedDirs[filepath.Dir(path)] = true
 			}
 		}
-	}()
-
-	dir := filepath.Dir(absPath)
-	if err := watcher.Add(dir); err != nil {
-		return fmt.Errorf("failed to watch directory: %w", err)
+		watcher.Add(path)
 	}
 
-	watcher.Add(absPath)
-
-	select {
-	case err := <-errChan:
-		return err
-	}
-}
-
-func WatchFiles(filePaths []string, config WatchConfig) error {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return fmt.Errorf("failed to create watcher: %w", err)
+	refreshDeps := func(owner string) {
+		if config.Dependencies == nil {
+			return
+		}
+		deps := config.Dependencies(owner)
+
+		mu.Lock()
+		defer mu.Unlock()
+		for dep, set := range owners {
+			delete(set, owner)
+			if len(set) == 0 {
+				delete(owners, dep)
+			}
+		}
+		for _, dep := range deps {
+			if owners[dep] == nil {
+				owners[dep] = make(map[string]bool)
+			}
+			owners[dep][owner] = true
+			watch(dep)
+		}
 	}
-	defer watcher.Close()
-
-	watchedFiles := make(map[string]bool)
-	watchedDirs := make(map[string]bool)
 
 	for _, fp := range filePaths {
 		absPath, err := filepath.Abs(fp)
@@ -101,6 +80,20 @@ func WatchFiles(filePaths []string, config WatchConfig) error {
 
 	errChan := make(chan error, 1)
 
+	schedule := func(target string) {
+		if timer, exists := debounceTimers[target]; exists && timer != nil {
+			timer.Stop()
+		}
+
+		debounceTimers[target] = time.AfterFunc(debounceDuration, func() {
+			if err := config.OnChange(target); err != nil {
+				timestamp := time.Now().Format("15:04:05")
+				fmt.Printf("[%s] Error: %v\n", timestamp, err)
+			}
+			refreshDeps(target)
+		})
+	}
+
 	go func() {
 		for {
 			select {
@@ -109,22 +102,26 @@ func WatchFiles(filePaths []string, config WatchConfig) error {
 					return
 				}
 
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Chmod) {
+					continue
+				}
+
 				eventPath, _ := filepath.Abs(event.Name)
 
+				mu.Lock()
+				var targets []string
 				if watchedFiles[eventPath] {
-					if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Chmod) {
-						if timer, exists := debounceTimers[eventPath]; exists && timer != nil {
-							timer.Stop()
-						}
-
-						debounceTimers[eventPath] = time.AfterFunc(debounceDuration, func() {
-							if err := config.OnChange(eventPath); err != nil {
-								timestamp := time.Now().Format("15:04:05")
-								fmt.Printf("[%s] Error: %v\n", timestamp, err)
-							}
-						})
+					targets = append(targets, eventPath)
+				}
+				for owner := range owners[eventPath] {
+					if owner != eventPath {
+						targets = append(targets, owner)
 					}
 				}
+				for _, target := range targets {
+					schedule(target)
+				}
+				mu.Unlock()
 
 			case err, ok := <-watcher.Errors:
 				if !ok {
@@ -144,6 +141,7 @@ func WatchFiles(filePaths []string, config WatchConfig) error {
 
 	for fp := range watchedFiles {
 		watcher.Add(fp)
+		refreshDeps(fp)
 	}
 
 	select {